@@ -24,10 +24,12 @@ import (
 	"log"
 	"net"
 
+	"github.com/utkarsh-pro/RapidoDB/pubsub"
 	"github.com/utkarsh-pro/RapidoDB/rql"
 	"github.com/utkarsh-pro/RapidoDB/security"
 	"github.com/utkarsh-pro/RapidoDB/store"
 	"github.com/utkarsh-pro/RapidoDB/transport"
+	"github.com/utkarsh-pro/RapidoDB/transport/resp"
 )
 
 const msg = `
@@ -49,17 +51,48 @@ type RapidoDB struct {
 	// PORT on which the server should run
 	PORT string
 
-	// Store that the RapidoDB will be using internally
-	store *store.Store
-
-	// Store that RapidoDB uses to store the DB users info
+	// RESPPort, if non-empty, starts a second listener that speaks
+	// the Redis Serialization Protocol on that port, alongside the
+	// RQL one, so redis-cli and Redis client libraries can talk to
+	// the same database.
+	RESPPort string
+
+	// Store that the RapidoDB will be using internally. This is a
+	// store.Backend rather than a concrete *store.Store so that
+	// --store can point RapidoDB at Redis/BoltDB/Postgres instead of
+	// the default in-memory map.
+	store store.Backend
+
+	// Store that RapidoDB uses to store the DB users info. User
+	// credentials always live in the in-memory store; there's no
+	// need to make this pluggable.
 	usersStore *store.Store
+
+	// broker is the single pub/sub broker shared by every connection,
+	// RQL and RESP alike, so a PUBLISH or keyspace notification on one
+	// connection reaches a SUBSCRIBE on any other.
+	broker *pubsub.Broker
 }
 
-// New returns an instance of the Server object
-func New(log *log.Logger, PORT, username, password string) *RapidoDB {
-	// Create a new store for the database
-	storage := store.New(store.NeverExpire)
+// New returns an instance of the Server object. storeURI selects the
+// storage backend (see store.Open); an empty string keeps the
+// original in-memory behaviour.
+//
+// walDir, if non-empty, turns the in-memory store into a durable one:
+// every mutation is written to a write-ahead log under walDir and
+// replayed from it on startup, so the server survives a crash. It is
+// ignored when storeURI selects a backend other than the default
+// in-memory one, since those are already durable on their own terms.
+// walFsync controls how the WAL is flushed ("always", "everysec" or
+// "no"); see store.ParseFsyncPolicy.
+//
+// respPort, if non-empty, starts the RESP listener in addition to the
+// RQL one on PORT; see RapidoDB.RESPPort.
+func New(log *log.Logger, PORT, respPort, storeURI, walDir, walFsync, username, password string) *RapidoDB {
+	storage, err := openStorage(storeURI, walDir, walFsync)
+	if err != nil {
+		log.Fatalf("Unable to open store: %s", err)
+	}
 
 	// Create a new store for the users
 	usersDB := store.New(store.NeverExpire)
@@ -68,28 +101,55 @@ func New(log *log.Logger, PORT, username, password string) *RapidoDB {
 		security.NewRegisteredUser(username, password, security.AdminAccess), usersDB.DefaultExpiry,
 	)
 
-	return &RapidoDB{log, PORT, storage, usersDB}
+	return &RapidoDB{log, PORT, respPort, storage, usersDB, pubsub.New()}
 }
 
-// Run method starts the TCP server and sets up the TCP client handlers
+// openStorage opens the backend for the database: a durable
+// WAL-backed store when walDir is set and storeURI selects the
+// default in-memory backend, the backend addressed by storeURI
+// otherwise (e.g. redis://, bolt:// or postgres://).
+func openStorage(storeURI, walDir, walFsync string) (store.Backend, error) {
+	if storeURI != "" || walDir == "" {
+		return store.Open(storeURI)
+	}
+
+	policy, err := store.ParseFsyncPolicy(walFsync)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.NewDurable(store.NeverExpire, walDir, policy)
+}
+
+// Run method starts the TCP server(s) and sets up the client
+// handlers. The RQL listener on PORT always runs; the RESP listener
+// only runs if RESPPort is set.
 func (s *RapidoDB) Run() {
-	listener := s.setupTCPServer()
+	fmt.Println(msg)
+
+	listener := s.setupTCPServer(s.PORT)
 	defer listener.Close()
+	s.log.Println("Started RQL server on PORT", s.PORT)
 
+	if s.RESPPort != "" {
+		respListener := s.setupTCPServer(s.RESPPort)
+		defer respListener.Close()
+		s.log.Println("Started RESP server on PORT", s.RESPPort)
+
+		go s.setupRESPClientHandler(respListener)
+	}
+
+	s.log.Println("Accepting Connections")
 	s.setupTCPClientHandler(listener)
 }
 
-// setupTCPServer starts a TCP server and returns the listener
-func (s *RapidoDB) setupTCPServer() net.Listener {
-	listener, err := net.Listen("tcp", ":"+s.PORT)
+// setupTCPServer listens on port and returns the listener
+func (s *RapidoDB) setupTCPServer(port string) net.Listener {
+	listener, err := net.Listen("tcp", ":"+port)
 	if err != nil {
 		s.log.Fatalf("Listen setup failed: %s", err)
 	}
 
-	fmt.Println(msg)
-	s.log.Println("Started server on PORT", s.PORT)
-	s.log.Println("Accepting Connections")
-
 	return listener
 }
 
@@ -115,7 +175,7 @@ func (s *RapidoDB) clientHandler(c net.Conn) {
 	s.log.Println("Connected: ", c.RemoteAddr().String())
 
 	// Create a translation driver for the client
-	transDriver := createTransDriver(s.store, s.usersStore)
+	transDriver := createTransDriver(s.store, s.usersStore, s.broker)
 
 	// Create a client
 	cl := transport.New(c, s.log, transDriver)
@@ -124,14 +184,48 @@ func (s *RapidoDB) clientHandler(c net.Conn) {
 	cl.InitRead()
 }
 
-func createTransDriver(store, udb security.UnsecureDB) *rql.Driver {
+func createTransDriver(store, udb security.UnsecureDB, broker *pubsub.Broker) *rql.Driver {
 	// Add the secure layer on the store
 	// This layer is not added by default as
 	// this layer has client specific authentication
 	// credentials which may or may not be common for
 	// all of the associated clients
-	sdb := security.New(store, udb)
+	sdb := security.New(store, udb, broker)
 
 	// Pass the secure store to the driver
 	return rql.New(sdb)
 }
+
+// setupRESPClientHandler sets up the RESP client handler via an
+// infinite loop, the RESP equivalent of setupTCPClientHandler
+func (s *RapidoDB) setupRESPClientHandler(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.log.Println("Unable to accept RESP connection: ", err.Error())
+			continue
+		}
+
+		go s.respClientHandler(conn)
+	}
+}
+
+func (s *RapidoDB) respClientHandler(c net.Conn) {
+	s.log.Println("Connected (RESP): ", c.RemoteAddr().String())
+
+	// Create a RESP translation driver for the client, same as
+	// createTransDriver does for RQL
+	respDriver := createRESPDriver(s.store, s.usersStore, s.broker)
+
+	cl := resp.NewClient(c, s.log, respDriver)
+	cl.InitRead()
+}
+
+func createRESPDriver(store, udb security.UnsecureDB, broker *pubsub.Broker) *resp.Driver {
+	// broker is the same *pubsub.Broker the RQL listener uses, so a
+	// redis-cli SUBSCRIBE sees a PUBLISH or keyspace event issued
+	// through RQL, and vice versa.
+	sdb := security.New(store, udb, broker)
+
+	return resp.NewDriver(sdb)
+}
@@ -0,0 +1,128 @@
+/*
+	pubsub package implements a small in-process publish/subscribe
+	broker. It has no notion of RQL, connections or the database - it
+	just fans messages published on a channel out to every subscriber
+	whose pattern matches that channel, the same way the storage
+	layer has no notion of RQL or transport.
+*/
+package pubsub
+
+import (
+	"sync"
+)
+
+// Event is a single message delivered to a subscriber.
+type Event struct {
+	// Channel is the exact channel the message was published on.
+	Channel string
+	// Message is the published payload.
+	Message string
+}
+
+// eventBuffer is how many unconsumed events a subscription holds
+// before Publish starts dropping them for that subscriber, so one
+// slow subscriber can't block every publisher.
+const eventBuffer = 64
+
+// Broker fans published events out to subscribers whose pattern
+// matches the published channel. The zero value is not usable; use
+// New.
+type Broker struct {
+	mu   sync.RWMutex
+	subs map[chan Event]string
+}
+
+// New returns an empty, ready to use Broker.
+func New() *Broker {
+	return &Broker{subs: make(map[chan Event]string)}
+}
+
+// Subscribe registers interest in every channel matching pattern (a
+// shell-style glob, e.g. "cache:*", where "*" also matches "/") and
+// returns a channel that matching Events are delivered on. Call
+// Unsubscribe with the same channel to stop receiving and release it.
+func (b *Broker) Subscribe(pattern string) <-chan Event {
+	ch := make(chan Event, eventBuffer)
+
+	b.mu.Lock()
+	b.subs[ch] = pattern
+	b.mu.Unlock()
+
+	return ch
+}
+
+// Unsubscribe stops delivering events to a channel previously
+// returned by Subscribe and closes it. It is a no-op if ch is not a
+// live subscription.
+func (b *Broker) Unsubscribe(ch <-chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for c := range b.subs {
+		if c == ch {
+			delete(b.subs, c)
+			close(c)
+			return
+		}
+	}
+}
+
+// Publish delivers an Event for message on channel to every
+// subscriber whose pattern matches, and returns how many subscribers
+// received it.
+func (b *Broker) Publish(channel, message string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	ev := Event{Channel: channel, Message: message}
+
+	delivered := 0
+	for ch, pattern := range b.subs {
+		if !globMatch(pattern, channel) {
+			continue
+		}
+
+		select {
+		case ch <- ev:
+			delivered++
+		default:
+			// subscriber too slow; drop rather than block the publisher
+		}
+	}
+
+	return delivered
+}
+
+// globMatch reports whether pattern matches s, where "*" matches any
+// run of characters (including none, and including "/") and "?"
+// matches exactly one character. It exists because path.Match treats
+// "/" as a separator "*" won't cross, which would silently drop
+// keyspace-notification subscriptions like "__keyspace@0__:*" against
+// channels containing a slash.
+func globMatch(pattern, s string) bool {
+	pIdx, sIdx := 0, 0
+	starIdx, starSIdx := -1, -1
+
+	for sIdx < len(s) {
+		switch {
+		case pIdx < len(pattern) && (pattern[pIdx] == '?' || pattern[pIdx] == s[sIdx]):
+			pIdx++
+			sIdx++
+		case pIdx < len(pattern) && pattern[pIdx] == '*':
+			starIdx, starSIdx = pIdx, sIdx
+			pIdx++
+		case starIdx != -1:
+			pIdx = starIdx + 1
+			starSIdx++
+			sIdx = starSIdx
+		default:
+			return false
+		}
+	}
+
+	for pIdx < len(pattern) && pattern[pIdx] == '*' {
+		pIdx++
+	}
+
+	return pIdx == len(pattern)
+}
@@ -0,0 +1,32 @@
+package pubsub
+
+import "testing"
+
+func TestBrokerPublishGlobCrossesSlash(t *testing.T) {
+	b := New()
+	ch := b.Subscribe("__keyspace@0__:*")
+	defer b.Unsubscribe(ch)
+
+	if n := b.Publish("__keyspace@0__:cache/orders/42", "set"); n != 1 {
+		t.Fatalf("Publish delivered to %d subscribers, want 1", n)
+	}
+
+	select {
+	case ev := <-ch:
+		if ev.Channel != "__keyspace@0__:cache/orders/42" {
+			t.Errorf("unexpected event channel %q", ev.Channel)
+		}
+	default:
+		t.Fatal("expected event to be delivered")
+	}
+}
+
+func TestBrokerPublishNoMatch(t *testing.T) {
+	b := New()
+	ch := b.Subscribe("other:*")
+	defer b.Unsubscribe(ch)
+
+	if n := b.Publish("cache:foo", "set"); n != 0 {
+		t.Fatalf("Publish delivered to %d subscribers, want 0", n)
+	}
+}
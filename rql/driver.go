@@ -3,7 +3,12 @@ package rql
 import (
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/utkarsh-pro/RapidoDB/pubsub"
+	"github.com/utkarsh-pro/RapidoDB/security"
 )
 
 // SecureDB interface defines the set of functions that RQL
@@ -11,7 +16,19 @@ import (
 type SecureDB interface {
 	Set(key string, data interface{}, expireIn time.Duration)
 	Get(key string) (interface{}, bool)
-	Authenticate(username string, password string) bool
+	Delete(keys ...string) int
+	Update(key string, data interface{}) bool
+	Exists(keys ...string) int
+	Incr(key string, by int64) (int64, error)
+	TTL(key string) (time.Duration, bool)
+	Expire(key string, expireIn time.Duration) bool
+	Authenticate(username string, password string) (security.Principal, bool)
+	DefaultPrincipal() security.Principal
+	CreateUser(username, password string) bool
+	Grant(username string, classes security.CommandClass, pattern string, allow bool) bool
+	Subscribe(pattern string) <-chan pubsub.Event
+	Unsubscribe(ch <-chan pubsub.Event)
+	Publish(channel, message string) int
 }
 
 // Driver is the RQL driver which acts as an interface between a database client and
@@ -22,11 +39,34 @@ type SecureDB interface {
 // of the database. Any database API that conforms this interface will work
 type Driver struct {
 	db SecureDB
+
+	// principal is the currently authenticated user for this
+	// connection; it starts out as db.DefaultPrincipal() and is
+	// replaced on a successful AUTH. Every data-touching statement is
+	// checked against it before being executed.
+	principal security.Principal
+
+	subsMu sync.Mutex
+	subs   map[string]<-chan pubsub.Event
 }
 
 // New function returns a pointer to an instance of RQL driver
 func New(db SecureDB) *Driver {
-	return &Driver{db}
+	return &Driver{db: db, principal: db.DefaultPrincipal(), subs: make(map[string]<-chan pubsub.Event)}
+}
+
+// Close unsubscribes the driver from every pattern it is currently
+// subscribed to. It should be called once the client connection this
+// Driver belongs to goes away, since Driver is created per connection
+// (see db.createTransDriver).
+func (d *Driver) Close() {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+
+	for pattern, ch := range d.subs {
+		d.db.Unsubscribe(ch)
+		delete(d.subs, pattern)
+	}
 }
 
 // Operate method can take in any RQL query and perform action
@@ -47,21 +87,119 @@ func (d *Driver) Operate(src string, w io.Writer) {
 	}
 
 	for _, stmt := range ast.Statements {
-		switch stmt.Typ {
+		if err := d.authorize(stmt); err != nil {
+			res("ERROR: "+err.Error(), w)
+			continue
+		}
+
+		switch stmt.typ {
 		case SetType:
 			res(d.set(stmt.SetStatement), w)
 		case GetType:
 			res(d.get(stmt.GetStatement), w)
+		case DeleteType:
+			res(d.del(stmt.DeleteStatement), w)
 		case AuthType:
 			res(d.auth(stmt.AuthStatement), w)
+		case UpdateType:
+			res(d.update(stmt.UpdateStatement), w)
+		case ExistsType:
+			res(d.exists(stmt.ExistsStatement), w)
+		case IncrType:
+			res(d.incr(stmt.IncrStatement), w)
+		case DecrType:
+			res(d.decr(stmt.DecrStatement), w)
+		case TTLType:
+			res(d.ttl(stmt.TTLStatement), w)
+		case ExpireType:
+			res(d.expire(stmt.ExpireStatement), w)
+		case SubscribeType:
+			res(d.subscribe(stmt.SubscribeStatement, w), w)
+		case UnsubscribeType:
+			res(d.unsubscribe(stmt.UnsubscribeStatement), w)
+		case PublishType:
+			res(d.publish(stmt.PublishStatement), w)
+		case CreateUserType:
+			res(d.createUser(stmt.CreateUserStatement), w)
+		case GrantType:
+			res(d.grant(stmt.GrantStatement), w)
+		case RevokeType:
+			res(d.revoke(stmt.RevokeStatement), w)
+		case WhoamiType:
+			res(d.whoami(), w)
 		}
 	}
 }
 
+// authorize checks stmt against the connection's current principal,
+// returning an error if it isn't allowed to run. AUTH and WHOAMI are
+// always allowed since a connection needs some way to authenticate
+// and introspect itself regardless of its current permissions.
+func (d *Driver) authorize(stmt *Statement) error {
+	class, keys := commandClassOf(stmt)
+	if class == 0 {
+		return nil
+	}
+	if len(keys) == 0 {
+		keys = []string{"*"}
+	}
+
+	for _, key := range keys {
+		if !d.principal.Can(class, key) {
+			return fmt.Errorf("permission denied for user %q on %q", d.principal.Username, key)
+		}
+	}
+
+	return nil
+}
+
+// commandClassOf returns the CommandClass a statement belongs to, and
+// the keys it touches (for per-key pattern matching). A zero
+// CommandClass means the statement needs no authorization.
+func commandClassOf(stmt *Statement) (security.CommandClass, []string) {
+	switch stmt.typ {
+	case GetType:
+		return security.ReadCommand, stmt.GetStatement.keys
+	case ExistsType:
+		return security.ReadCommand, stmt.ExistsStatement.keys
+	case TTLType:
+		return security.ReadCommand, []string{stmt.TTLStatement.key}
+	case SetType:
+		return security.WriteCommand, []string{stmt.SetStatement.key}
+	case UpdateType:
+		return security.WriteCommand, []string{stmt.UpdateStatement.key}
+	case DeleteType:
+		return security.WriteCommand, stmt.DeleteStatement.keys
+	case IncrType:
+		return security.WriteCommand, []string{stmt.IncrStatement.key}
+	case DecrType:
+		return security.WriteCommand, []string{stmt.DecrStatement.key}
+	case ExpireType:
+		return security.WriteCommand, []string{stmt.ExpireStatement.key}
+	case SubscribeType, UnsubscribeType, PublishType:
+		return security.PubSubCommand, nil
+	case CreateUserType, GrantType, RevokeType:
+		return security.AdminCommand, nil
+	default:
+		return 0, nil
+	}
+}
+
 // set method calls the set method on the database by providing
 // appropriate parameters
 func (d *Driver) set(stmt *SetStatement) string {
-	d.db.Set(stmt.key, stmt.val, convertToDuration(stmt.exp))
+	if stmt.expKind == KeepTTL {
+		remaining, _ := d.db.TTL(stmt.key)
+		d.db.Set(stmt.key, stmt.val, remaining)
+
+		return "Success"
+	}
+
+	expireIn, alreadyExpired := resolveExpiry(stmt)
+	d.db.Set(stmt.key, stmt.val, expireIn)
+	if alreadyExpired {
+		d.db.Delete(stmt.key)
+	}
 
 	return "Success"
 }
@@ -88,11 +226,176 @@ func (d *Driver) get(stmt *GetStatement) string {
 }
 
 func (d *Driver) auth(stmt *AuthStatement) string {
-	if d.db.Authenticate(stmt.username, stmt.password) {
-		return "Successfully authenticated"
+	principal, ok := d.db.Authenticate(stmt.username, stmt.password)
+	if !ok {
+		return "Invalid Credentials"
+	}
+
+	d.principal = principal
+	return "Successfully authenticated"
+}
+
+// del method calls the delete method on the database and returns the
+// number of keys that were actually removed
+func (d *Driver) del(stmt *DeleteStatement) string {
+	return stringify(d.db.Delete(stmt.keys...))
+}
+
+// update method replaces the value stored under key without touching
+// its existing expiry
+func (d *Driver) update(stmt *UpdateStatement) string {
+	if d.db.Update(stmt.key, stmt.val) {
+		return "Success"
+	}
+
+	return "ERROR: key does not exist"
+}
+
+// exists method returns how many of the given keys are present
+func (d *Driver) exists(stmt *ExistsStatement) string {
+	return stringify(d.db.Exists(stmt.keys...))
+}
+
+// incr method increments the integer stored under key by stmt.by and
+// returns the resulting value
+func (d *Driver) incr(stmt *IncrStatement) string {
+	val, err := d.db.Incr(stmt.key, stmt.by)
+	if err != nil {
+		return "ERROR: " + err.Error()
+	}
+
+	return stringify(val)
+}
+
+// decr method decrements the integer stored under key by stmt.by. It
+// is implemented in terms of Incr with a negated amount since the
+// underlying semantics are identical
+func (d *Driver) decr(stmt *DecrStatement) string {
+	val, err := d.db.Incr(stmt.key, -stmt.by)
+	if err != nil {
+		return "ERROR: " + err.Error()
+	}
+
+	return stringify(val)
+}
+
+// ttl method returns the remaining time to live for key, in seconds,
+// or -1 if the key has no expiry and -2 if it doesn't exist
+func (d *Driver) ttl(stmt *TTLStatement) string {
+	remaining, ok := d.db.TTL(stmt.key)
+	if !ok {
+		return stringify(-2)
+	}
+	if remaining == 0 {
+		return stringify(-1)
+	}
+
+	return stringify(int64(remaining.Seconds()))
+}
+
+// expire method sets (or replaces) the expiry on an existing key
+func (d *Driver) expire(stmt *ExpireStatement) string {
+	if d.db.Expire(stmt.key, time.Duration(stmt.seconds)*time.Second) {
+		return "Success"
+	}
+
+	return "ERROR: key does not exist"
+}
+
+// subscribe method subscribes the connection to every pattern in
+// stmt that it isn't already subscribed to, and starts forwarding
+// matching events to w as they arrive
+func (d *Driver) subscribe(stmt *SubscribeStatement, w io.Writer) string {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+
+	for _, pattern := range stmt.patterns {
+		if _, ok := d.subs[pattern]; ok {
+			continue
+		}
+
+		ch := d.db.Subscribe(pattern)
+		d.subs[pattern] = ch
+
+		go forwardEvents(ch, w)
+	}
+
+	return "Subscribed to " + strings.Join(stmt.patterns, ", ")
+}
+
+// unsubscribe method stops forwarding events for every pattern in
+// stmt, or every pattern the connection is currently subscribed to if
+// stmt has none
+func (d *Driver) unsubscribe(stmt *UnsubscribeStatement) string {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+
+	patterns := stmt.patterns
+	if len(patterns) == 0 {
+		for pattern := range d.subs {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if ch, ok := d.subs[pattern]; ok {
+			d.db.Unsubscribe(ch)
+			delete(d.subs, pattern)
+		}
+	}
+
+	return "Unsubscribed from " + strings.Join(patterns, ", ")
+}
+
+// publish method publishes stmt's message on stmt's channel and
+// returns how many subscribers received it
+func (d *Driver) publish(stmt *PublishStatement) string {
+	return stringify(d.db.Publish(stmt.channel, stmt.message))
+}
+
+// createUser method registers a new user with no permissions; GRANT
+// is needed afterwards before it can do anything
+func (d *Driver) createUser(stmt *CreateUserStatement) string {
+	if d.db.CreateUser(stmt.username, stmt.password) {
+		return "Success"
+	}
+
+	return "ERROR: user already exists"
+}
+
+// grant method adds an allow rule over stmt's command classes and key
+// pattern to stmt's user
+func (d *Driver) grant(stmt *GrantStatement) string {
+	if d.db.Grant(stmt.username, stmt.classes, stmt.pattern, true) {
+		return "Success"
 	}
 
-	return "Invalid Credentials"
+	return "ERROR: user does not exist"
+}
+
+// revoke method adds a deny rule over stmt's command classes and key
+// pattern to stmt's user, overriding any matching GRANT that came
+// before it
+func (d *Driver) revoke(stmt *RevokeStatement) string {
+	if d.db.Grant(stmt.username, stmt.classes, stmt.pattern, false) {
+		return "Success"
+	}
+
+	return "ERROR: user does not exist"
+}
+
+// whoami method returns the username of the connection's current
+// principal
+func (d *Driver) whoami() string {
+	return d.principal.Username
+}
+
+// forwardEvents writes every Event received on ch to w as a MESSAGE
+// frame until ch is closed (by Unsubscribe or Driver.Close)
+func forwardEvents(ch <-chan pubsub.Event, w io.Writer) {
+	for ev := range ch {
+		fmt.Fprintf(w, "MESSAGE %s %s\n", ev.Channel, ev.Message)
+	}
 }
 
 // errRes function is supposed to write error messages to the
@@ -112,12 +415,38 @@ func res(msg string, w io.Writer) {
 
 // ============================ HELPER FUNCTIONS ===================================
 
-// convertToDuration converts uint to time.Duration object.
-// This uint is supposed to be in MILLISECONDS.
-// It's internally converted into nanoseconds and is then casted into
-// time.Duration object
-func convertToDuration(t uint) time.Duration {
-	return time.Duration(t * 1000)
+// resolveExpiry turns stmt's parsed expiry clause (everything but
+// KEEPTTL, which Driver.set handles itself since it needs to read the
+// key's current TTL) into the relative time.Duration Set expects. The
+// second return value reports whether the target instant has already
+// passed - EXAT/PXAT in the past, or exactly now - which would
+// otherwise collide with the zero value Set treats as "never expire".
+func resolveExpiry(stmt *SetStatement) (expireIn time.Duration, alreadyExpired bool) {
+	switch stmt.expKind {
+	case ExpireInMillis:
+		return time.Duration(stmt.expVal) * time.Millisecond, false
+	case ExpireInSeconds:
+		return time.Duration(stmt.expVal) * time.Second, false
+	case ExpireAtSeconds:
+		return untilOrExpired(time.Unix(stmt.expVal, 0))
+	case ExpireAtMillis:
+		return untilOrExpired(time.UnixMilli(stmt.expVal))
+	default:
+		return 0, false
+	}
+}
+
+// untilOrExpired returns the duration from now until target. A
+// target at or before now returns a zero duration with alreadyExpired
+// set, since Set's zero value on its own means "never expire", not
+// "expire immediately".
+func untilOrExpired(target time.Time) (expireIn time.Duration, alreadyExpired bool) {
+	expireIn = time.Until(target)
+	if expireIn <= 0 {
+		return 0, true
+	}
+
+	return expireIn, false
 }
 
 // stringify function can be used to stringify any data type
@@ -0,0 +1,85 @@
+package rql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveExpiry(t *testing.T) {
+	tests := []struct {
+		name         string
+		stmt         *SetStatement
+		wantExpired  bool
+		wantDuration time.Duration
+	}{
+		{
+			"NO EXPIRY",
+			&SetStatement{expKind: NoExpiry},
+			false,
+			0,
+		},
+		{
+			"EX SECONDS",
+			&SetStatement{expKind: ExpireInSeconds, expVal: 10},
+			false,
+			10 * time.Second,
+		},
+		{
+			"PX MILLISECONDS",
+			&SetStatement{expKind: ExpireInMillis, expVal: 10000},
+			false,
+			10000 * time.Millisecond,
+		},
+		{
+			"EXAT IN THE FUTURE",
+			&SetStatement{expKind: ExpireAtSeconds, expVal: time.Now().Add(time.Hour).Unix()},
+			false,
+			0, // checked separately below, since it's relative to time.Now()
+		},
+		{
+			"EXAT IN THE PAST",
+			&SetStatement{expKind: ExpireAtSeconds, expVal: time.Now().Add(-time.Hour).Unix()},
+			true,
+			0,
+		},
+		{
+			"PXAT IN THE PAST",
+			&SetStatement{expKind: ExpireAtMillis, expVal: time.Now().Add(-time.Hour).UnixMilli()},
+			true,
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, expired := resolveExpiry(tt.stmt)
+			if expired != tt.wantExpired {
+				t.Errorf("resolveExpiry() expired = %v, want %v", expired, tt.wantExpired)
+			}
+			if tt.name == "EXAT IN THE FUTURE" {
+				if got <= 0 || got > time.Hour {
+					t.Errorf("resolveExpiry() = %v, want a positive duration up to 1h", got)
+				}
+				return
+			}
+			if !expired && got != tt.wantDuration {
+				t.Errorf("resolveExpiry() = %v, want %v", got, tt.wantDuration)
+			}
+		})
+	}
+}
+
+// TestUntilOrExpiredBoundary exercises the exact boundary the bug
+// this helper fixes was about: a target of "now" must be reported as
+// already expired, not as a bare zero duration, since Set's zero value
+// means "never expire", not "expire immediately".
+func TestUntilOrExpiredBoundary(t *testing.T) {
+	now := time.Now()
+
+	expireIn, alreadyExpired := untilOrExpired(now)
+	if !alreadyExpired {
+		t.Fatalf("untilOrExpired(now) expired = false, want true")
+	}
+	if expireIn != 0 {
+		t.Fatalf("untilOrExpired(now) = %v, want 0", expireIn)
+	}
+}
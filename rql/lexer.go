@@ -0,0 +1,122 @@
+package rql
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenType enumerates the kinds of lexical tokens the lexer can
+// produce.
+type tokenType int
+
+const (
+	tokEOF tokenType = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokSemicolon
+)
+
+// token is a single lexical token produced by the lexer, ready to be
+// consumed by the parser.
+type token struct {
+	typ tokenType
+	val string
+}
+
+// lexer turns RQL source into a stream of tokens. It is deliberately
+// small: RQL has no nested expressions, so a single left-to-right
+// scan with no backtracking is enough.
+type lexer struct {
+	src []rune
+	pos int
+}
+
+// newLexer returns a lexer positioned at the start of src.
+func newLexer(src string) *lexer {
+	return &lexer{src: []rune(src)}
+}
+
+// next returns the next token in the source, or a tokEOF token once
+// the source is exhausted.
+func (l *lexer) next() token {
+	l.skipWhitespace()
+
+	if l.pos >= len(l.src) {
+		return token{typ: tokEOF}
+	}
+
+	ch := l.src[l.pos]
+
+	switch {
+	case ch == ';':
+		l.pos++
+		return token{typ: tokSemicolon, val: ";"}
+	case ch == '"':
+		return l.lexString()
+	case unicode.IsDigit(ch) || (ch == '-' && l.peekIsDigit()):
+		return l.lexNumber()
+	default:
+		return l.lexIdent()
+	}
+}
+
+func (l *lexer) skipWhitespace() {
+	for l.pos < len(l.src) && unicode.IsSpace(l.src[l.pos]) {
+		l.pos++
+	}
+}
+
+func (l *lexer) peekIsDigit() bool {
+	return l.pos+1 < len(l.src) && unicode.IsDigit(l.src[l.pos+1])
+}
+
+// lexString consumes a double-quoted string literal. Escaping isn't
+// supported; RQL string literals are plain text between quotes.
+func (l *lexer) lexString() token {
+	l.pos++ // consume opening quote
+
+	start := l.pos
+	for l.pos < len(l.src) && l.src[l.pos] != '"' {
+		l.pos++
+	}
+
+	val := string(l.src[start:l.pos])
+	if l.pos < len(l.src) {
+		l.pos++ // consume closing quote
+	}
+
+	return token{typ: tokString, val: val}
+}
+
+func (l *lexer) lexNumber() token {
+	start := l.pos
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	for l.pos < len(l.src) && unicode.IsDigit(l.src[l.pos]) {
+		l.pos++
+	}
+
+	return token{typ: tokNumber, val: string(l.src[start:l.pos])}
+}
+
+// lexIdent consumes a bare word: a keyword, a key, or an unquoted
+// value that doesn't start with a digit. Numeric-looking values (e.g.
+// the 3454 in `SET data1 3454 565;`) are lexed by lexNumber instead,
+// and the parser still treats them as string values.
+func (l *lexer) lexIdent() token {
+	start := l.pos
+	for l.pos < len(l.src) && !unicode.IsSpace(l.src[l.pos]) && l.src[l.pos] != ';' {
+		l.pos++
+	}
+
+	return token{typ: tokIdent, val: string(l.src[start:l.pos])}
+}
+
+// keyword reports whether val matches keyword ignoring case, which is
+// how RQL statement names are matched (`SET`, `set` and `Set` are all
+// accepted).
+func keyword(val, keyword string) bool {
+	return strings.EqualFold(val, keyword)
+}
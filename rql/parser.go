@@ -0,0 +1,558 @@
+package rql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/utkarsh-pro/RapidoDB/security"
+)
+
+// StatementType identifies which concrete statement a Statement
+// wraps.
+type StatementType int
+
+// The set of statement types the parser can produce. Driver.Operate
+// switches on these to decide which handler to invoke.
+const (
+	SetType StatementType = iota
+	GetType
+	DeleteType
+	AuthType
+	UpdateType
+	ExistsType
+	IncrType
+	DecrType
+	TTLType
+	ExpireType
+	SubscribeType
+	UnsubscribeType
+	PublishType
+	CreateUserType
+	GrantType
+	RevokeType
+	WhoamiType
+)
+
+// Ast is the result of parsing a batch of RQL statements.
+type Ast struct {
+	Statements []*Statement
+}
+
+// Statement wraps exactly one of the concrete statement types below;
+// typ says which one.
+type Statement struct {
+	typ StatementType
+
+	SetStatement         *SetStatement
+	GetStatement         *GetStatement
+	DeleteStatement      *DeleteStatement
+	AuthStatement        *AuthStatement
+	UpdateStatement      *UpdateStatement
+	ExistsStatement      *ExistsStatement
+	IncrStatement        *IncrStatement
+	DecrStatement        *DecrStatement
+	TTLStatement         *TTLStatement
+	ExpireStatement      *ExpireStatement
+	SubscribeStatement   *SubscribeStatement
+	UnsubscribeStatement *UnsubscribeStatement
+	PublishStatement     *PublishStatement
+	CreateUserStatement  *CreateUserStatement
+	GrantStatement       *GrantStatement
+	RevokeStatement      *RevokeStatement
+}
+
+// ExpiryKind identifies which flavour of expiry clause, if any, a
+// SetStatement carries, mirroring the EX/PX/EXAT/PXAT/KEEPTTL options
+// mature KV stores expose on SET.
+type ExpiryKind int
+
+const (
+	// NoExpiry means SET didn't specify an expiry at all; the key is
+	// stored with a zero expiry, which every backend treats as "never
+	// expire".
+	NoExpiry ExpiryKind = iota
+	// ExpireInMillis is SET's original bare-number form, or PX: a
+	// number of milliseconds relative to now.
+	ExpireInMillis
+	// ExpireInSeconds is EX: a number of seconds relative to now.
+	ExpireInSeconds
+	// ExpireAtSeconds is EXAT: an absolute Unix timestamp, in seconds.
+	ExpireAtSeconds
+	// ExpireAtMillis is PXAT: an absolute Unix timestamp, in
+	// milliseconds.
+	ExpireAtMillis
+	// KeepTTL is KEEPTTL: leave the key's existing expiry untouched.
+	KeepTTL
+)
+
+// SetStatement represents `SET key val [expiry]`. expiry is left
+// unresolved - expKind says how to interpret expVal - so that turning
+// it into an actual time.Duration (which EXAT/PXAT need time.Now()
+// for) is Driver's job, not the parser's.
+type SetStatement struct {
+	key string
+	val string
+
+	expKind ExpiryKind
+	expVal  int64 // meaningless when expKind is NoExpiry or KeepTTL
+}
+
+// GetStatement represents `GET key...`.
+type GetStatement struct {
+	keys []string
+}
+
+// DeleteStatement represents `DEL key...`.
+type DeleteStatement struct {
+	keys []string
+}
+
+// AuthStatement represents `AUTH username password`.
+type AuthStatement struct {
+	username string
+	password string
+}
+
+// UpdateStatement represents `UPDATE key val`. Unlike SET it doesn't
+// touch the key's existing expiry.
+type UpdateStatement struct {
+	key string
+	val string
+}
+
+// ExistsStatement represents `EXISTS key...`.
+type ExistsStatement struct {
+	keys []string
+}
+
+// IncrStatement represents `INCR key [by]`. by defaults to 1 when
+// omitted.
+type IncrStatement struct {
+	key string
+	by  int64
+}
+
+// DecrStatement represents `DECR key [by]`. by defaults to 1 when
+// omitted.
+type DecrStatement struct {
+	key string
+	by  int64
+}
+
+// TTLStatement represents `TTL key`, which queries the remaining time
+// to live for key.
+type TTLStatement struct {
+	key string
+}
+
+// ExpireStatement represents `EXPIRE key seconds`, which sets (or
+// replaces) the expiry on an existing key.
+type ExpireStatement struct {
+	key     string
+	seconds uint
+}
+
+// SubscribeStatement represents `SUBSCRIBE pattern...`.
+type SubscribeStatement struct {
+	patterns []string
+}
+
+// UnsubscribeStatement represents `UNSUBSCRIBE [pattern...]`. No
+// patterns means unsubscribe from everything.
+type UnsubscribeStatement struct {
+	patterns []string
+}
+
+// PublishStatement represents `PUBLISH channel message`.
+type PublishStatement struct {
+	channel string
+	message string
+}
+
+// CreateUserStatement represents `CREATEUSER username password`.
+type CreateUserStatement struct {
+	username string
+	password string
+}
+
+// GrantStatement represents `GRANT username classes pattern`, classes
+// being a comma-separated list such as `READ,WRITE`.
+type GrantStatement struct {
+	username string
+	classes  security.CommandClass
+	pattern  string
+}
+
+// RevokeStatement represents `REVOKE username classes pattern`; it
+// parses identically to GRANT but is applied as a deny rule.
+type RevokeStatement struct {
+	username string
+	classes  security.CommandClass
+	pattern  string
+}
+
+// Parse parses src, a semicolon-separated batch of RQL statements,
+// into an Ast. It returns a nil Ast and nil error for a batch with no
+// statements (e.g. blank input).
+func Parse(src string) (*Ast, error) {
+	l := newLexer(src)
+
+	var statements []*Statement
+	for {
+		tok := l.next()
+		if tok.typ == tokEOF {
+			break
+		}
+		if tok.typ != tokIdent {
+			return nil, fmt.Errorf("rql: expected a statement keyword, got %q", tok.val)
+		}
+
+		stmt, err := parseStatement(l, tok.val)
+		if err != nil {
+			return nil, err
+		}
+
+		statements = append(statements, stmt)
+	}
+
+	if statements == nil {
+		return nil, nil
+	}
+
+	return &Ast{Statements: statements}, nil
+}
+
+// parseStatement dispatches on the leading keyword kw and parses the
+// rest of the statement up to (and including) its terminating
+// semicolon.
+func parseStatement(l *lexer, kw string) (*Statement, error) {
+	switch {
+	case keyword(kw, "SET"):
+		return parseSet(l)
+	case keyword(kw, "GET"):
+		return parseGet(l)
+	case keyword(kw, "DEL"):
+		return parseDelete(l)
+	case keyword(kw, "AUTH"):
+		return parseAuth(l)
+	case keyword(kw, "UPDATE"):
+		return parseUpdate(l)
+	case keyword(kw, "EXISTS"):
+		return parseExists(l)
+	case keyword(kw, "INCR"):
+		return parseIncr(l)
+	case keyword(kw, "DECR"):
+		return parseDecr(l)
+	case keyword(kw, "TTL"):
+		return parseTTL(l)
+	case keyword(kw, "EXPIRE"):
+		return parseExpire(l)
+	case keyword(kw, "SUBSCRIBE"):
+		return parseSubscribe(l)
+	case keyword(kw, "UNSUBSCRIBE"):
+		return parseUnsubscribe(l)
+	case keyword(kw, "PUBLISH"):
+		return parsePublish(l)
+	case keyword(kw, "CREATEUSER"):
+		return parseCreateUser(l)
+	case keyword(kw, "GRANT"):
+		return parseGrant(l)
+	case keyword(kw, "REVOKE"):
+		return parseRevoke(l)
+	case keyword(kw, "WHOAMI"):
+		return parseWhoami(l)
+	default:
+		return nil, fmt.Errorf("rql: unknown statement %q", kw)
+	}
+}
+
+// readArgs reads tokens up to (and consuming) the next tokSemicolon
+// or tokEOF, returning everything in between.
+func readArgs(l *lexer) []token {
+	var toks []token
+	for {
+		t := l.next()
+		if t.typ == tokSemicolon || t.typ == tokEOF {
+			break
+		}
+		toks = append(toks, t)
+	}
+
+	return toks
+}
+
+func words(toks []token) []string {
+	out := make([]string, len(toks))
+	for i, t := range toks {
+		out[i] = t.val
+	}
+
+	return out
+}
+
+func parseSet(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) < 2 {
+		return nil, fmt.Errorf("rql: SET requires a key and a value")
+	}
+
+	stmt := &SetStatement{key: args[0].val, val: args[1].val}
+
+	if len(args) >= 3 {
+		if err := parseSetExpiry(stmt, words(args[2:])); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Statement{typ: SetType, SetStatement: stmt}, nil
+}
+
+// parseSetExpiry parses SET's optional trailing expiry clause into
+// stmt: a bare number of milliseconds (the original, unitless form),
+// KEEPTTL on its own, or one of EX/PX/EXAT/PXAT followed by a number.
+func parseSetExpiry(stmt *SetStatement, rest []string) error {
+	unit := strings.ToUpper(rest[0])
+
+	if unit == "KEEPTTL" {
+		if len(rest) != 1 {
+			return fmt.Errorf("rql: KEEPTTL takes no argument")
+		}
+
+		stmt.expKind = KeepTTL
+		return nil
+	}
+
+	if len(rest) == 1 {
+		ms, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("rql: invalid SET expiry %q: %w", rest[0], err)
+		}
+
+		stmt.expKind = ExpireInMillis
+		stmt.expVal = ms
+		return nil
+	}
+
+	if len(rest) != 2 {
+		return fmt.Errorf("rql: invalid SET expiry %q", strings.Join(rest, " "))
+	}
+
+	n, err := strconv.ParseInt(rest[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("rql: invalid SET expiry %q: %w", rest[1], err)
+	}
+
+	switch unit {
+	case "EX":
+		stmt.expKind = ExpireInSeconds
+	case "PX":
+		stmt.expKind = ExpireInMillis
+	case "EXAT":
+		stmt.expKind = ExpireAtSeconds
+	case "PXAT":
+		stmt.expKind = ExpireAtMillis
+	default:
+		return fmt.Errorf("rql: unknown SET expiry unit %q", rest[0])
+	}
+	stmt.expVal = n
+
+	return nil
+}
+
+func parseGet(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("rql: GET requires at least one key")
+	}
+
+	return &Statement{typ: GetType, GetStatement: &GetStatement{keys: words(args)}}, nil
+}
+
+func parseDelete(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("rql: DEL requires at least one key")
+	}
+
+	return &Statement{typ: DeleteType, DeleteStatement: &DeleteStatement{keys: words(args)}}, nil
+}
+
+func parseAuth(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) != 2 {
+		return nil, fmt.Errorf("rql: AUTH requires a username and a password")
+	}
+
+	return &Statement{typ: AuthType, AuthStatement: &AuthStatement{
+		username: args[0].val,
+		password: args[1].val,
+	}}, nil
+}
+
+func parseUpdate(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) < 2 {
+		return nil, fmt.Errorf("rql: UPDATE requires a key and a value")
+	}
+
+	return &Statement{typ: UpdateType, UpdateStatement: &UpdateStatement{
+		key: args[0].val,
+		val: args[1].val,
+	}}, nil
+}
+
+func parseExists(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("rql: EXISTS requires at least one key")
+	}
+
+	return &Statement{typ: ExistsType, ExistsStatement: &ExistsStatement{keys: words(args)}}, nil
+}
+
+func parseIncr(l *lexer) (*Statement, error) {
+	return parseIncrDecr(l, IncrType)
+}
+
+func parseDecr(l *lexer) (*Statement, error) {
+	return parseIncrDecr(l, DecrType)
+}
+
+func parseIncrDecr(l *lexer, typ StatementType) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("rql: INCR/DECR requires a key")
+	}
+
+	by := int64(1)
+	if len(args) >= 2 {
+		parsed, err := strconv.ParseInt(args[1].val, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("rql: invalid INCR/DECR amount %q: %w", args[1].val, err)
+		}
+		by = parsed
+	}
+
+	stmt := &Statement{typ: typ}
+	if typ == IncrType {
+		stmt.IncrStatement = &IncrStatement{key: args[0].val, by: by}
+	} else {
+		stmt.DecrStatement = &DecrStatement{key: args[0].val, by: by}
+	}
+
+	return stmt, nil
+}
+
+func parseTTL(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) != 1 {
+		return nil, fmt.Errorf("rql: TTL requires exactly one key")
+	}
+
+	return &Statement{typ: TTLType, TTLStatement: &TTLStatement{key: args[0].val}}, nil
+}
+
+func parseExpire(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) != 2 {
+		return nil, fmt.Errorf("rql: EXPIRE requires a key and a number of seconds")
+	}
+
+	seconds, err := strconv.ParseUint(args[1].val, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("rql: invalid EXPIRE seconds %q: %w", args[1].val, err)
+	}
+
+	return &Statement{typ: ExpireType, ExpireStatement: &ExpireStatement{
+		key:     args[0].val,
+		seconds: uint(seconds),
+	}}, nil
+}
+
+func parseSubscribe(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("rql: SUBSCRIBE requires at least one pattern")
+	}
+
+	return &Statement{typ: SubscribeType, SubscribeStatement: &SubscribeStatement{patterns: words(args)}}, nil
+}
+
+func parseUnsubscribe(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+
+	return &Statement{typ: UnsubscribeType, UnsubscribeStatement: &UnsubscribeStatement{patterns: words(args)}}, nil
+}
+
+func parsePublish(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) < 2 {
+		return nil, fmt.Errorf("rql: PUBLISH requires a channel and a message")
+	}
+
+	return &Statement{typ: PublishType, PublishStatement: &PublishStatement{
+		channel: args[0].val,
+		message: args[1].val,
+	}}, nil
+}
+
+func parseCreateUser(l *lexer) (*Statement, error) {
+	args := readArgs(l)
+	if len(args) != 2 {
+		return nil, fmt.Errorf("rql: CREATEUSER requires a username and a password")
+	}
+
+	return &Statement{typ: CreateUserType, CreateUserStatement: &CreateUserStatement{
+		username: args[0].val,
+		password: args[1].val,
+	}}, nil
+}
+
+func parseGrant(l *lexer) (*Statement, error) {
+	username, classes, pattern, err := parseGrantArgs(l, "GRANT")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Statement{typ: GrantType, GrantStatement: &GrantStatement{
+		username: username,
+		classes:  classes,
+		pattern:  pattern,
+	}}, nil
+}
+
+func parseRevoke(l *lexer) (*Statement, error) {
+	username, classes, pattern, err := parseGrantArgs(l, "REVOKE")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Statement{typ: RevokeType, RevokeStatement: &RevokeStatement{
+		username: username,
+		classes:  classes,
+		pattern:  pattern,
+	}}, nil
+}
+
+// parseGrantArgs parses the shared `username classes pattern` grammar
+// GRANT and REVOKE have in common.
+func parseGrantArgs(l *lexer, name string) (username string, classes security.CommandClass, pattern string, err error) {
+	args := readArgs(l)
+	if len(args) != 3 {
+		return "", 0, "", fmt.Errorf("rql: %s requires a username, command classes and a key pattern", name)
+	}
+
+	classes, err = security.ParseCommandClasses(args[1].val)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	return args[0].val, classes, args[2].val, nil
+}
+
+func parseWhoami(l *lexer) (*Statement, error) {
+	readArgs(l)
+
+	return &Statement{typ: WhoamiType}, nil
+}
@@ -3,6 +3,8 @@ package rql
 import (
 	"reflect"
 	"testing"
+
+	"github.com/utkarsh-pro/RapidoDB/security"
 )
 
 func TestParse(t *testing.T) {
@@ -38,9 +40,10 @@ func TestParse(t *testing.T) {
 				Statements: []*Statement{
 					{
 						SetStatement: &SetStatement{
-							key: "data",
-							val: "Hello World",
-							exp: 234,
+							key:     "data",
+							val:     "Hello World",
+							expKind: ExpireInMillis,
+							expVal:  234,
 						},
 						typ: SetType,
 					},
@@ -55,17 +58,108 @@ func TestParse(t *testing.T) {
 				Statements: []*Statement{
 					{
 						SetStatement: &SetStatement{
-							key: "data",
-							val: "Hello World",
-							exp: 234,
+							key:     "data",
+							val:     "Hello World",
+							expKind: ExpireInMillis,
+							expVal:  234,
+						},
+						typ: SetType,
+					},
+					{
+						SetStatement: &SetStatement{
+							key:     "data1",
+							val:     "3454",
+							expKind: ExpireInMillis,
+							expVal:  565,
 						},
 						typ: SetType,
 					},
+				},
+			},
+			false,
+		},
+		{
+			"SET STATEMENT WITH EX",
+			args{`SET data "Hello World" EX 10;`},
+			&Ast{
+				Statements: []*Statement{
 					{
 						SetStatement: &SetStatement{
-							key: "data1",
-							val: "3454",
-							exp: 565,
+							key:     "data",
+							val:     "Hello World",
+							expKind: ExpireInSeconds,
+							expVal:  10,
+						},
+						typ: SetType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"SET STATEMENT WITH PX",
+			args{`SET data "Hello World" PX 10000;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						SetStatement: &SetStatement{
+							key:     "data",
+							val:     "Hello World",
+							expKind: ExpireInMillis,
+							expVal:  10000,
+						},
+						typ: SetType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"SET STATEMENT WITH EXAT",
+			args{`SET data "Hello World" EXAT 1893456000;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						SetStatement: &SetStatement{
+							key:     "data",
+							val:     "Hello World",
+							expKind: ExpireAtSeconds,
+							expVal:  1893456000,
+						},
+						typ: SetType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"SET STATEMENT WITH PXAT",
+			args{`SET data "Hello World" PXAT 1893456000000;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						SetStatement: &SetStatement{
+							key:     "data",
+							val:     "Hello World",
+							expKind: ExpireAtMillis,
+							expVal:  1893456000000,
+						},
+						typ: SetType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"SET STATEMENT WITH KEEPTTL",
+			args{`SET data "Hello World" KEEPTTL;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						SetStatement: &SetStatement{
+							key:     "data",
+							val:     "Hello World",
+							expKind: KeepTTL,
 						},
 						typ: SetType,
 					},
@@ -143,6 +237,193 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"UPDATE STATEMENT",
+			args{`UPDATE data "Hello Again";`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						UpdateStatement: &UpdateStatement{
+							key: "data",
+							val: "Hello Again",
+						},
+						typ: UpdateType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"EXISTS STATEMENT",
+			args{`EXISTS data data1;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						ExistsStatement: &ExistsStatement{
+							keys: []string{"data", "data1"},
+						},
+						typ: ExistsType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"INCR STATEMENT",
+			args{`INCR counter;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						IncrStatement: &IncrStatement{
+							key: "counter",
+							by:  1,
+						},
+						typ: IncrType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"DECR STATEMENT WITH AMOUNT",
+			args{`DECR counter 5;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						DecrStatement: &DecrStatement{
+							key: "counter",
+							by:  5,
+						},
+						typ: DecrType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"TTL STATEMENT",
+			args{`TTL data;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						TTLStatement: &TTLStatement{
+							key: "data",
+						},
+						typ: TTLType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"EXPIRE STATEMENT",
+			args{`EXPIRE data 60;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						ExpireStatement: &ExpireStatement{
+							key:     "data",
+							seconds: 60,
+						},
+						typ: ExpireType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"SUBSCRIBE STATEMENT",
+			args{`SUBSCRIBE cache:* events;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						SubscribeStatement: &SubscribeStatement{
+							patterns: []string{"cache:*", "events"},
+						},
+						typ: SubscribeType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"PUBLISH STATEMENT",
+			args{`PUBLISH events "deploy finished";`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						PublishStatement: &PublishStatement{
+							channel: "events",
+							message: "deploy finished",
+						},
+						typ: PublishType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"CREATEUSER STATEMENT",
+			args{`CREATEUSER bob secret;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						CreateUserStatement: &CreateUserStatement{
+							username: "bob",
+							password: "secret",
+						},
+						typ: CreateUserType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"GRANT STATEMENT",
+			args{`GRANT bob READ,WRITE cache:*;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						GrantStatement: &GrantStatement{
+							username: "bob",
+							classes:  security.ReadCommand | security.WriteCommand,
+							pattern:  "cache:*",
+						},
+						typ: GrantType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"REVOKE STATEMENT",
+			args{`REVOKE bob WRITE cache:*;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						RevokeStatement: &RevokeStatement{
+							username: "bob",
+							classes:  security.WriteCommand,
+							pattern:  "cache:*",
+						},
+						typ: RevokeType,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"WHOAMI STATEMENT",
+			args{`WHOAMI;`},
+			&Ast{
+				Statements: []*Statement{
+					{
+						typ: WhoamiType,
+					},
+				},
+			},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,167 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CommandClass groups RQL statements into the categories GRANT/REVOKE
+// rules are written against. It's a bitmask so a single Rule can
+// cover several classes at once.
+type CommandClass int
+
+const (
+	// ReadCommand covers GET, EXISTS and TTL.
+	ReadCommand CommandClass = 1 << iota
+	// WriteCommand covers SET, UPDATE, DEL, INCR, DECR and EXPIRE.
+	WriteCommand
+	// AdminCommand covers CREATEUSER, GRANT and REVOKE.
+	AdminCommand
+	// PubSubCommand covers SUBSCRIBE, UNSUBSCRIBE and PUBLISH.
+	PubSubCommand
+)
+
+// classNames maps the names accepted by GRANT/REVOKE to their
+// CommandClass, used by both ParseCommandClasses and its inverse.
+var classNames = map[string]CommandClass{
+	"READ":   ReadCommand,
+	"WRITE":  WriteCommand,
+	"ADMIN":  AdminCommand,
+	"PUBSUB": PubSubCommand,
+}
+
+// ParseCommandClasses parses a comma-separated list of class names
+// (e.g. "READ,WRITE") into a CommandClass bitmask.
+func ParseCommandClasses(s string) (CommandClass, error) {
+	var classes CommandClass
+
+	for _, name := range strings.Split(s, ",") {
+		class, ok := classNames[strings.ToUpper(name)]
+		if !ok {
+			return 0, fmt.Errorf("security: unknown command class %q", name)
+		}
+		classes |= class
+	}
+
+	return classes, nil
+}
+
+// Rule grants or denies a set of command classes over keys matching
+// KeyPattern (a shell-style glob, e.g. "cache:*", or "*" for every
+// key). Unlike path.Match, "*" here matches "/" too, since RapidoDB
+// keys routinely contain one (e.g. "cache:orders/42").
+type Rule struct {
+	Commands   CommandClass
+	KeyPattern string
+	Allow      bool
+}
+
+// Role is a named set of Rules. The last rule matching a given
+// (class, key) pair wins, and the default with no matching rule is
+// deny - the same evaluation order real ACL systems (and iptables)
+// use, so that a REVOKE can be expressed as an appended deny rule
+// without having to locate and remove the grant it overrides.
+//
+// A Role is shared by every connection authenticated as the user it
+// belongs to, so Rules is guarded by mu: GRANT from one connection
+// races Allowed's read from another.
+type Role struct {
+	Name  string
+	Rules []Rule
+
+	mu sync.RWMutex
+}
+
+// Allowed reports whether class is permitted against key under r.
+func (r *Role) Allowed(class CommandClass, key string) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	allowed := false
+	for _, rule := range r.Rules {
+		if rule.Commands&class == 0 {
+			continue
+		}
+		if !globMatch(rule.KeyPattern, key) {
+			continue
+		}
+
+		allowed = rule.Allow
+	}
+
+	return allowed
+}
+
+// Grant appends an allow/deny rule over classes and pattern to r.
+// allow is false for REVOKE, which is modelled as an appended deny
+// rule rather than an actual removal - see Role's doc comment.
+func (r *Role) Grant(classes CommandClass, pattern string, allow bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Rules = append(r.Rules, Rule{Commands: classes, KeyPattern: pattern, Allow: allow})
+}
+
+// globMatch reports whether pattern matches s, where "*" matches any
+// run of characters (including none, and including "/") and "?"
+// matches exactly one character. It exists because path.Match treats
+// "/" as a separator "*" won't cross, which silently breaks both the
+// AdminRole's own "*" pattern and any "cache:*"-style rule against a
+// key containing a slash.
+func globMatch(pattern, s string) bool {
+	pIdx, sIdx := 0, 0
+	starIdx, starSIdx := -1, -1
+
+	for sIdx < len(s) {
+		switch {
+		case pIdx < len(pattern) && (pattern[pIdx] == '?' || pattern[pIdx] == s[sIdx]):
+			pIdx++
+			sIdx++
+		case pIdx < len(pattern) && pattern[pIdx] == '*':
+			starIdx, starSIdx = pIdx, sIdx
+			pIdx++
+		case starIdx != -1:
+			pIdx = starIdx + 1
+			starSIdx++
+			sIdx = starSIdx
+		default:
+			return false
+		}
+	}
+
+	for pIdx < len(pattern) && pattern[pIdx] == '*' {
+		pIdx++
+	}
+
+	return pIdx == len(pattern)
+}
+
+// AdminRole returns a Role with unrestricted access to every command
+// class and key. It's the role given to the bootstrap admin user
+// created by db.New.
+func AdminRole() *Role {
+	return &Role{
+		Name: "admin",
+		Rules: []Rule{
+			{Commands: ReadCommand | WriteCommand | AdminCommand | PubSubCommand, KeyPattern: "*", Allow: true},
+		},
+	}
+}
+
+// Principal identifies the authenticated user behind a connection. A
+// zero-value Principal (no Role) can do nothing.
+type Principal struct {
+	Username string
+	Role     *Role
+}
+
+// Can reports whether p is allowed to run a command in class against
+// key.
+func (p Principal) Can(class CommandClass, key string) bool {
+	return p.Role.Allowed(class, key)
+}
@@ -0,0 +1,237 @@
+/*
+	security package sits between the RQL driver and the storage
+	layer. It owns authentication and, in doing so, decouples the
+	rest of the system from knowing how users are stored: rql.Driver
+	only ever sees the SecureDB interface it declares, which Security
+	here satisfies by delegating to an UnsecureDB.
+*/
+package security
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/utkarsh-pro/RapidoDB/pubsub"
+)
+
+// keyspaceChannel returns the channel a keyspace notification for key
+// is published on, Redis-style.
+func keyspaceChannel(key string) string {
+	return fmt.Sprintf("__keyspace@0__:%s", key)
+}
+
+// AccessLevel is the coarse-grained access level NewRegisteredUser
+// accepts; it's translated into a starting Role.
+type AccessLevel int
+
+const (
+	// NoAccess starts the user with an empty Role; GRANT is needed
+	// before it can do anything.
+	NoAccess AccessLevel = iota
+	// AdminAccess starts the user with AdminRole, unrestricted access
+	// to every command class and key.
+	AdminAccess
+)
+
+// defaultUsername is the principal unauthenticated connections run
+// as.
+const defaultUsername = "default"
+
+// UnsecureDB is the interface Security wraps. It's implemented by
+// store.Backend (and, for the users store, *store.Store); "unsecure"
+// because, unlike SecureDB, it performs no authentication of its own.
+type UnsecureDB interface {
+	Set(key string, data interface{}, expireIn time.Duration)
+	Get(key string) (interface{}, bool)
+	Delete(keys ...string) int
+	Update(key string, data interface{}) bool
+	Exists(keys ...string) int
+	Incr(key string, by int64) (int64, error)
+	TTL(key string) (time.Duration, bool)
+	Expire(key string, expireIn time.Duration) bool
+}
+
+// RegisteredUser holds a user's credentials and Role, as stored in
+// the users UnsecureDB.
+type RegisteredUser struct {
+	Username string
+	password string
+	Role     *Role
+}
+
+// NewRegisteredUser returns a new RegisteredUser. access picks its
+// starting Role: AdminAccess for unrestricted access, NoAccess for an
+// empty Role that GRANT must be used to populate.
+func NewRegisteredUser(username, password string, access AccessLevel) *RegisteredUser {
+	role := &Role{Name: username}
+	if access == AdminAccess {
+		role = AdminRole()
+	}
+
+	return &RegisteredUser{
+		Username: username,
+		password: password,
+		Role:     role,
+	}
+}
+
+// Security wraps a database and a users store, and satisfies
+// rql.SecureDB by delegating every data method straight through to db
+// while handling Authenticate itself against users.
+type Security struct {
+	db     UnsecureDB
+	users  UnsecureDB
+	broker *pubsub.Broker
+}
+
+// New returns a new Security layered on top of db, authenticating
+// against users. It seeds a "default" user with an empty Role if one
+// doesn't already exist, so unauthenticated connections have a
+// Principal to carry even before any GRANT has run.
+//
+// broker is the pub/sub broker shared across every connection; it
+// must be the same *pubsub.Broker for all Security instances in a
+// RapidoDB, otherwise a PUBLISH or keyspace notification on one
+// connection is never delivered to a SUBSCRIBE on another.
+func New(db, users UnsecureDB, broker *pubsub.Broker) *Security {
+	if _, ok := users.Get(defaultUsername); !ok {
+		users.Set(defaultUsername, NewRegisteredUser(defaultUsername, "", NoAccess), 0)
+	}
+
+	return &Security{db: db, users: users, broker: broker}
+}
+
+// Set delegates to the underlying database, then fires a "set"
+// keyspace notification for key.
+func (s *Security) Set(key string, data interface{}, expireIn time.Duration) {
+	s.db.Set(key, data, expireIn)
+	s.broker.Publish(keyspaceChannel(key), "set")
+}
+
+// Get delegates to the underlying database.
+func (s *Security) Get(key string) (interface{}, bool) {
+	return s.db.Get(key)
+}
+
+// Delete delegates to the underlying database, then fires a "del"
+// keyspace notification for every key.
+func (s *Security) Delete(keys ...string) int {
+	removed := s.db.Delete(keys...)
+
+	for _, key := range keys {
+		s.broker.Publish(keyspaceChannel(key), "del")
+	}
+
+	return removed
+}
+
+// Update delegates to the underlying database.
+func (s *Security) Update(key string, data interface{}) bool {
+	return s.db.Update(key, data)
+}
+
+// Exists delegates to the underlying database.
+func (s *Security) Exists(keys ...string) int {
+	return s.db.Exists(keys...)
+}
+
+// Incr delegates to the underlying database.
+func (s *Security) Incr(key string, by int64) (int64, error) {
+	return s.db.Incr(key, by)
+}
+
+// TTL delegates to the underlying database.
+func (s *Security) TTL(key string) (time.Duration, bool) {
+	return s.db.TTL(key)
+}
+
+// Expire delegates to the underlying database, then fires an
+// "expire" keyspace notification for key if it existed.
+func (s *Security) Expire(key string, expireIn time.Duration) bool {
+	ok := s.db.Expire(key, expireIn)
+	if ok {
+		s.broker.Publish(keyspaceChannel(key), "expire")
+	}
+
+	return ok
+}
+
+// Subscribe registers interest in every channel matching pattern.
+func (s *Security) Subscribe(pattern string) <-chan pubsub.Event {
+	return s.broker.Subscribe(pattern)
+}
+
+// Unsubscribe stops delivering events to a channel previously
+// returned by Subscribe.
+func (s *Security) Unsubscribe(ch <-chan pubsub.Event) {
+	s.broker.Unsubscribe(ch)
+}
+
+// Publish publishes message on channel and returns how many
+// subscribers received it.
+func (s *Security) Publish(channel, message string) int {
+	return s.broker.Publish(channel, message)
+}
+
+// Authenticate checks username/password against the users store and
+// returns the resulting Principal for the caller to carry through the
+// rest of the connection.
+func (s *Security) Authenticate(username, password string) (Principal, bool) {
+	user, ok := s.lookupUser(username)
+	if !ok || user.password != password {
+		return Principal{}, false
+	}
+
+	return Principal{Username: user.Username, Role: user.Role}, true
+}
+
+// DefaultPrincipal returns the Principal unauthenticated connections
+// run as.
+func (s *Security) DefaultPrincipal() Principal {
+	user, ok := s.lookupUser(defaultUsername)
+	if !ok {
+		return Principal{Username: defaultUsername}
+	}
+
+	return Principal{Username: user.Username, Role: user.Role}
+}
+
+// CreateUser registers a new user with an empty Role; GRANT is
+// needed before it can do anything. It reports false if username
+// already exists.
+func (s *Security) CreateUser(username, password string) bool {
+	if _, ok := s.users.Get(username); ok {
+		return false
+	}
+
+	s.users.Set(username, NewRegisteredUser(username, password, NoAccess), 0)
+	return true
+}
+
+// Grant adds an allow/deny rule over classes and pattern to
+// username's Role. allow is false for REVOKE, which is modelled as
+// an appended deny rule rather than an actual removal - see Role. It
+// reports false if username doesn't exist.
+func (s *Security) Grant(username string, classes CommandClass, pattern string, allow bool) bool {
+	user, ok := s.lookupUser(username)
+	if !ok {
+		return false
+	}
+
+	user.Role.Grant(classes, pattern, allow)
+	s.users.Set(username, user, 0)
+
+	return true
+}
+
+// lookupUser fetches and type-asserts a RegisteredUser from the
+// users store.
+func (s *Security) lookupUser(username string) (*RegisteredUser, bool) {
+	val, ok := s.users.Get(username)
+	if !ok {
+		return nil, false
+	}
+
+	user, ok := val.(*RegisteredUser)
+	return user, ok
+}
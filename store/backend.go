@@ -0,0 +1,78 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Backend is the storage abstraction every concrete implementation
+// (in-memory Store, Redis, an embedded KV store, Postgres) must
+// satisfy. security and rql.Driver only ever talk to a Backend, so
+// swapping the backing store never touches RQL or the transport
+// layer.
+type Backend interface {
+	Set(key string, data interface{}, expireIn time.Duration)
+	Get(key string) (interface{}, bool)
+	Delete(keys ...string) int
+	Update(key string, data interface{}) bool
+	Exists(keys ...string) int
+	Incr(key string, by int64) (int64, error)
+	TTL(key string) (time.Duration, bool)
+	Expire(key string, expireIn time.Duration) bool
+}
+
+// clients caches one Backend per URI so that multiple connections
+// asking for the same store (e.g. the main store and a future
+// replica reader) share a single underlying client/connection pool
+// instead of dialing twice.
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[string]Backend)
+)
+
+// Open returns the Backend addressed by uri, reusing an existing
+// connection for uri if one has already been opened by this process.
+//
+// Supported schemes are:
+//
+//	(empty)     in-memory Store, the default
+//	redis://    Redis, via RedisBackend
+//	bolt://     an embedded BoltDB/LevelDB KV store, via BoltBackend
+//	postgres:// Postgres, via PostgresBackend
+func Open(uri string) (Backend, error) {
+	if uri == "" {
+		return New(DefaultExpiry), nil
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	if b, ok := clients[uri]; ok {
+		return b, nil
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("store: invalid uri %q: %w", uri, err)
+	}
+
+	var b Backend
+	switch parsed.Scheme {
+	case "redis":
+		b, err = newRedisBackend(parsed)
+	case "bolt":
+		b, err = newBoltBackend(parsed)
+	case "postgres", "postgresql":
+		b, err = newPostgresBackend(parsed)
+	default:
+		return nil, fmt.Errorf("store: unknown backend scheme %q", parsed.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	clients[uri] = b
+	return b, nil
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBucket is the single bucket every key/value pair is stored in.
+// RapidoDB doesn't expose namespacing above the key itself, so one
+// bucket is sufficient.
+var boltBucket = []byte("rapidodb")
+
+// BoltBackend is a Backend implementation backed by an embedded
+// BoltDB file. BoltDB has no notion of TTL, so expiry is tracked in
+// memory via a ttlSweeper and enforced on read, with the sweeper also
+// removing expired keys from disk in the background.
+type BoltBackend struct {
+	db      *bolt.DB
+	sweeper *ttlSweeper
+}
+
+// newBoltBackend opens (creating if necessary) the BoltDB file
+// addressed by uri, e.g. bolt:///var/lib/rapidodb.db.
+func newBoltBackend(uri *url.URL) (*BoltBackend, error) {
+	path := uri.Path
+	if path == "" {
+		path = uri.Opaque
+	}
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("store: unable to open bolt db at %q: %w", path, err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("store: unable to create bucket: %w", err)
+	}
+
+	b := &BoltBackend{db: db}
+	b.sweeper = newTTLSweeper(b.evict)
+
+	return b, nil
+}
+
+// Set stores data under key, marshalled with fmt.Sprintf("%v", ...)
+// since BoltDB only deals in bytes.
+func (b *BoltBackend) Set(key string, data interface{}, expireIn time.Duration) {
+	b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), []byte(fmt.Sprintf("%v", data)))
+	})
+
+	b.sweeper.track(key, expireIn)
+}
+
+// Get returns the value stored under key. The second return value is
+// false if the key doesn't exist or has expired.
+func (b *BoltBackend) Get(key string) (interface{}, bool) {
+	if b.sweeper.isExpired(key) {
+		b.evict(key)
+		return nil, false
+	}
+
+	var val []byte
+	b.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(boltBucket).Get([]byte(key)); v != nil {
+			val = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	if val == nil {
+		return nil, false
+	}
+
+	return string(val), true
+}
+
+// evict removes key from the underlying file; it is used both as the
+// ttlSweeper's eviction callback and for expiry found on read.
+func (b *BoltBackend) evict(key string) {
+	b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(key))
+	})
+}
+
+// Delete removes keys and returns how many of them actually existed.
+func (b *BoltBackend) Delete(keys ...string) int {
+	removed := 0
+	for _, key := range keys {
+		if _, ok := b.Get(key); ok {
+			removed++
+		}
+		b.evict(key)
+	}
+
+	return removed
+}
+
+// Update replaces the value stored under an existing key, keeping its
+// tracked expiry in place.
+func (b *BoltBackend) Update(key string, data interface{}) bool {
+	if _, ok := b.Get(key); !ok {
+		return false
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), []byte(fmt.Sprintf("%v", data)))
+	}) == nil
+}
+
+// Exists returns how many of the given keys are present and
+// unexpired.
+func (b *BoltBackend) Exists(keys ...string) int {
+	count := 0
+	for _, key := range keys {
+		if _, ok := b.Get(key); ok {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Incr adds by to the integer stored under key (creating it with
+// value 0 first if it doesn't exist yet) and returns the resulting
+// value.
+func (b *BoltBackend) Incr(key string, by int64) (int64, error) {
+	var current int64
+	existed := false
+	if val, ok := b.Get(key); ok {
+		parsed, err := strconv.ParseInt(fmt.Sprintf("%v", val), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("store: value for key %q is not an integer", key)
+		}
+		current = parsed
+		existed = true
+	}
+
+	next := current + by
+	b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), []byte(fmt.Sprintf("%v", next)))
+	})
+
+	// A missing/expired key means the sweeper may still hold a
+	// past expiry for it (Get's eviction only removes it from the
+	// file, not from the sweeper's tracked expiry); clear that now
+	// or the freshly written value reads back as expired.
+	if !existed {
+		b.sweeper.track(key, NeverExpire)
+	}
+
+	return next, nil
+}
+
+// TTL returns the remaining time to live for key. The second return
+// value is false if the key doesn't exist.
+func (b *BoltBackend) TTL(key string) (time.Duration, bool) {
+	if _, ok := b.Get(key); !ok {
+		return 0, false
+	}
+
+	return b.sweeper.remaining(key), true
+}
+
+// Expire sets (or replaces) the expiry tracked for an existing key.
+func (b *BoltBackend) Expire(key string, expireIn time.Duration) bool {
+	if _, ok := b.Get(key); !ok {
+		return false
+	}
+
+	b.sweeper.track(key, expireIn)
+	return true
+}
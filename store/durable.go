@@ -0,0 +1,29 @@
+package store
+
+import "time"
+
+// snapshotInterval is how often a durable Store's background
+// compaction goroutine snapshots the map and truncates the WAL.
+const snapshotInterval = 5 * time.Minute
+
+// NewDurable returns an in-memory Store backed by a write-ahead log
+// rooted at dir, recovering any state left behind by a previous
+// process before returning: the latest snapshot is loaded first, then
+// the WAL is tailed forward from there. Callers should call this (and
+// let it finish) before accepting any client connections.
+func NewDurable(defaultExpiry time.Duration, dir string, policy FsyncPolicy) (*Store, error) {
+	wal, err := openWAL(dir, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	s := New(defaultExpiry)
+	if err := wal.replay(s); err != nil {
+		return nil, err
+	}
+
+	s.wal = wal
+	go wal.compactionLoop(s, snapshotInterval)
+
+	return s, nil
+}
@@ -0,0 +1,250 @@
+/*
+	store package provides the storage layer for RapidoDB.
+
+	Store is the default in-memory Backend implementation: a simple
+	map guarded by a mutex. It existed long before the Backend
+	abstraction (see backend.go) and remains the default when no
+	--store flag is supplied, so existing deployments keep working
+	unchanged.
+*/
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NeverExpire is used as the expiry duration for items that should
+// never be evicted.
+const NeverExpire = 0
+
+// DefaultExpiry is the expiry duration used when none is specified.
+const DefaultExpiry = NeverExpire
+
+// Item represents a single value held by the in-memory Store, along
+// with the (optional) wall-clock time at which it should be treated
+// as expired.
+type Item struct {
+	expireAt int64
+	data     interface{}
+}
+
+// newItem creates a new Item and returns it
+func newItem(data interface{}, expireIn time.Duration) Item {
+	var expiry int64 = NeverExpire
+	if expireIn != NeverExpire {
+		expiry = time.Now().Add(expireIn).UnixNano()
+	}
+
+	return Item{
+		expireAt: expiry,
+		data:     data,
+	}
+}
+
+// isExpired checks if an item is expired
+func (item Item) isExpired() bool {
+	if item.expireAt == NeverExpire {
+		return false
+	}
+
+	return item.expireAt < time.Now().UnixNano()
+}
+
+// Store is the in-memory Backend implementation. It is safe for
+// concurrent use.
+type Store struct {
+	mu            sync.RWMutex
+	data          map[string]Item
+	DefaultExpiry time.Duration
+
+	// wal is non-nil when the Store was opened via NewDurable, in
+	// which case Set/Delete/Expire append to it before touching data.
+	wal *WAL
+}
+
+// New returns a new, empty in-memory Store. defaultExpiry is kept in
+// the DefaultExpiry field for callers to pass to Set; Set itself does
+// not apply it, and an expireIn of NeverExpire always means the key
+// never expires.
+func New(defaultExpiry time.Duration) *Store {
+	return &Store{
+		data:          make(map[string]Item),
+		DefaultExpiry: defaultExpiry,
+	}
+}
+
+// Set stores data under key, expiring it after expireIn (NeverExpire
+// to keep it around forever).
+func (s *Store) Set(key string, data interface{}, expireIn time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := newItem(data, expireIn)
+	if s.wal != nil {
+		s.wal.append(opSet, key, data, item.expireAt)
+	}
+
+	s.data[key] = item
+}
+
+// Get returns the value stored under key. The second return value is
+// false if the key doesn't exist or has expired.
+func (s *Store) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.get(key)
+}
+
+// get is the lock-free counterpart of Get, for use by methods that
+// already hold s.mu.
+func (s *Store) get(key string) (interface{}, bool) {
+	item, ok := s.data[key]
+	if !ok || item.isExpired() {
+		return nil, false
+	}
+
+	return item.data, true
+}
+
+// Delete removes keys from the store and returns how many of them
+// actually existed.
+func (s *Store) Delete(keys ...string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for _, key := range keys {
+		if _, ok := s.get(key); ok {
+			removed++
+		}
+		if s.wal != nil {
+			s.wal.append(opDelete, key, nil, NeverExpire)
+		}
+		delete(s.data, key)
+	}
+
+	return removed
+}
+
+// Update replaces the value stored under an existing key without
+// touching its expiry. It reports whether key existed.
+func (s *Store) Update(key string, data interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.get(key)
+	_ = item
+	if !ok {
+		return false
+	}
+
+	existing := s.data[key]
+	if s.wal != nil {
+		s.wal.append(opSet, key, data, existing.expireAt)
+	}
+	existing.data = data
+	s.data[key] = existing
+
+	return true
+}
+
+// Exists returns how many of the given keys are present and
+// unexpired.
+func (s *Store) Exists(keys ...string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, key := range keys {
+		if _, ok := s.get(key); ok {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Incr adds by to the integer stored under key (creating it with
+// value 0 first if it doesn't exist yet) and returns the resulting
+// value. It errors if the existing value isn't an integer.
+func (s *Store) Incr(key string, by int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current int64
+	expireAt := int64(NeverExpire)
+	if val, ok := s.get(key); ok {
+		parsed, err := toInt64(val)
+		if err != nil {
+			return 0, fmt.Errorf("store: value for key %q is not an integer", key)
+		}
+		current = parsed
+		expireAt = s.data[key].expireAt
+	}
+
+	next := current + by
+
+	if s.wal != nil {
+		s.wal.append(opSet, key, next, expireAt)
+	}
+	s.data[key] = Item{expireAt: expireAt, data: next}
+
+	return next, nil
+}
+
+// TTL returns the remaining time to live for key. The second return
+// value is false if the key doesn't exist; a returned duration of
+// NeverExpire means the key exists but never expires.
+func (s *Store) TTL(key string) (time.Duration, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.data[key]
+	if !ok || item.isExpired() {
+		return 0, false
+	}
+	if item.expireAt == NeverExpire {
+		return NeverExpire, true
+	}
+
+	return time.Until(time.Unix(0, item.expireAt)), true
+}
+
+// Expire sets (or replaces) the expiry on an existing key. It reports
+// whether key existed.
+func (s *Store) Expire(key string, expireIn time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.get(key)
+	if !ok {
+		return false
+	}
+
+	item := newItem(data, expireIn)
+	if s.wal != nil {
+		s.wal.append(opExpire, key, data, item.expireAt)
+	}
+
+	s.data[key] = item
+
+	return true
+}
+
+// toInt64 converts a stored value to an int64, accepting both the
+// int64 Incr itself produces and the string form a value arrives in
+// from RQL.
+func toInt64(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return strconv.ParseInt(fmt.Sprintf("%v", v), 10, 64)
+	}
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreIncrAfterExpiryStartsFresh(t *testing.T) {
+	s := New(NeverExpire)
+
+	s.Set("x", "5", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	got, err := s.Incr("x", 10)
+	if err != nil {
+		t.Fatalf("Incr: %v", err)
+	}
+	if got != 10 {
+		t.Fatalf("Incr = %d, want 10", got)
+	}
+
+	val, ok := s.Get("x")
+	if !ok {
+		t.Fatal("Get(x) after Incr on an expired key should find the new value, not report it expired")
+	}
+	if val != int64(10) {
+		t.Fatalf("Get(x) = %v, want 10", val)
+	}
+}
+
+func TestStoreIncrPreservesExpiry(t *testing.T) {
+	s := New(NeverExpire)
+
+	s.Set("x", "5", time.Hour)
+	if _, err := s.Incr("x", 1); err != nil {
+		t.Fatalf("Incr: %v", err)
+	}
+
+	ttl, ok := s.TTL("x")
+	if !ok {
+		t.Fatal("x should still exist")
+	}
+	if ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("TTL(x) = %v, want roughly an hour", ttl)
+	}
+}
+
+func TestStoreUpdatePreservesExpiry(t *testing.T) {
+	s := New(NeverExpire)
+
+	s.Set("x", "a", time.Hour)
+	if !s.Update("x", "b") {
+		t.Fatal("Update should report true for an existing key")
+	}
+
+	val, ok := s.Get("x")
+	if !ok || val != "b" {
+		t.Fatalf("Get(x) = (%v, %v), want (\"b\", true)", val, ok)
+	}
+
+	ttl, ok := s.TTL("x")
+	if !ok || ttl <= 0 {
+		t.Fatalf("TTL(x) = (%v, %v), want a positive remaining TTL", ttl, ok)
+	}
+}
+
+func TestStoreUpdateExpiredKeyFails(t *testing.T) {
+	s := New(NeverExpire)
+
+	s.Set("x", "a", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	if s.Update("x", "b") {
+		t.Fatal("Update should report false for an expired key")
+	}
+}
+
+func TestStoreExpireBoundary(t *testing.T) {
+	s := New(NeverExpire)
+
+	s.Set("x", "a", NeverExpire)
+	if !s.Expire("x", time.Nanosecond) {
+		t.Fatal("Expire should report true for an existing key")
+	}
+
+	time.Sleep(time.Millisecond)
+
+	if _, ok := s.Get("x"); ok {
+		t.Fatal("x should be expired once its expireAt has passed")
+	}
+}
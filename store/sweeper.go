@@ -0,0 +1,101 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often ttlSweeper scans for expired keys on
+// backends that have no native TTL support of their own.
+const sweepInterval = time.Second
+
+// ttlSweeper adds software TTL on top of a backend whose underlying
+// client doesn't expire keys for us (e.g. a plain BoltDB bucket).
+// Embedding it in a backend, calling track from Set/Expire (track with
+// NeverExpire clears an expiry) and isExpired from Get, gives that backend the same expiry semantics as the in-memory
+// Store, at the cost of a background goroutine per opened backend.
+type ttlSweeper struct {
+	mu      sync.Mutex
+	expiry  map[string]time.Time
+	onEvict func(key string)
+}
+
+// newTTLSweeper starts the background sweeper goroutine. It runs for
+// the lifetime of the process; backends are process-lifetime
+// singletons (see the clients cache in backend.go) so there is no
+// corresponding stop.
+func newTTLSweeper(onEvict func(key string)) *ttlSweeper {
+	s := &ttlSweeper{
+		expiry:  make(map[string]time.Time),
+		onEvict: onEvict,
+	}
+
+	go s.loop()
+
+	return s
+}
+
+// track records that key should be considered expired after
+// expireIn. A zero expireIn clears any existing expiry for key.
+func (s *ttlSweeper) track(key string, expireIn time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expireIn == NeverExpire {
+		delete(s.expiry, key)
+		return
+	}
+
+	s.expiry[key] = time.Now().Add(expireIn)
+}
+
+// isExpired reports whether key has a tracked expiry that is in the
+// past. Keys with no tracked expiry are never considered expired by
+// the sweeper (the backend itself is the source of truth for whether
+// they exist at all).
+func (s *ttlSweeper) isExpired(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at, ok := s.expiry[key]
+	return ok && !time.Now().Before(at)
+}
+
+// remaining returns how long until key's tracked expiry, or
+// NeverExpire if key has no tracked expiry.
+func (s *ttlSweeper) remaining(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at, ok := s.expiry[key]
+	if !ok {
+		return NeverExpire
+	}
+
+	return time.Until(at)
+}
+
+func (s *ttlSweeper) loop() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+
+		var expired []string
+		s.mu.Lock()
+		for key, at := range s.expiry {
+			if !now.Before(at) {
+				expired = append(expired, key)
+			}
+		}
+		for _, key := range expired {
+			delete(s.expiry, key)
+		}
+		s.mu.Unlock()
+
+		for _, key := range expired {
+			s.onEvict(key)
+		}
+	}
+}
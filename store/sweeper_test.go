@@ -0,0 +1,56 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLSweeperTrackAndIsExpired(t *testing.T) {
+	s := newTTLSweeper(func(key string) {})
+
+	s.track("a", time.Hour)
+	if s.isExpired("a") {
+		t.Fatal("a should not be expired yet")
+	}
+
+	s.track("b", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if !s.isExpired("b") {
+		t.Fatal("b should be expired")
+	}
+
+	if s.isExpired("missing") {
+		t.Fatal("untracked key should never be reported expired")
+	}
+}
+
+func TestTTLSweeperTrackClearsWithNeverExpire(t *testing.T) {
+	s := newTTLSweeper(func(key string) {})
+
+	s.track("a", time.Nanosecond)
+	s.track("a", NeverExpire)
+
+	time.Sleep(time.Millisecond)
+	if s.isExpired("a") {
+		t.Fatal("NeverExpire should clear the tracked expiry")
+	}
+	if s.remaining("a") != NeverExpire {
+		t.Fatalf("remaining(a) = %v, want NeverExpire", s.remaining("a"))
+	}
+}
+
+func TestTTLSweeperEvicts(t *testing.T) {
+	evicted := make(chan string, 1)
+	s := newTTLSweeper(func(key string) { evicted <- key })
+
+	s.track("a", time.Nanosecond)
+
+	select {
+	case key := <-evicted:
+		if key != "a" {
+			t.Fatalf("evicted %q, want %q", key, "a")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for sweeper to evict expired key")
+	}
+}
@@ -0,0 +1,519 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FsyncPolicy controls how aggressively the WAL flushes to disk.
+type FsyncPolicy int
+
+const (
+	// FsyncAlways fsyncs after every single append. Safest, slowest.
+	FsyncAlways FsyncPolicy = iota
+	// FsyncEverySec fsyncs once a second from a background goroutine.
+	FsyncEverySec
+	// FsyncNever never explicitly fsyncs, leaving it to the OS.
+	FsyncNever
+)
+
+// ParseFsyncPolicy parses the --wal-fsync flag value ("always",
+// "everysec" or "no") into a FsyncPolicy.
+func ParseFsyncPolicy(s string) (FsyncPolicy, error) {
+	switch s {
+	case "always":
+		return FsyncAlways, nil
+	case "everysec", "":
+		return FsyncEverySec, nil
+	case "no":
+		return FsyncNever, nil
+	default:
+		return 0, fmt.Errorf("store: unknown fsync policy %q", s)
+	}
+}
+
+// walOp identifies which mutation a walRecord represents.
+type walOp int
+
+const (
+	opSet walOp = iota
+	opDelete
+	opExpire
+)
+
+// walRecord is a single mutating RQL statement as appended to the
+// WAL. RequestNum increases monotonically and is what
+// RecoverFromRequestNumber resumes from.
+type walRecord struct {
+	RequestNum uint64      `json:"n"`
+	Op         walOp       `json:"op"`
+	Key        string      `json:"k"`
+	Value      interface{} `json:"v,omitempty"`
+	ExpireAt   int64       `json:"e"`
+}
+
+// segmentMaxRecords is how many records a single WAL segment holds
+// before a new one is rolled. Keeping segments small is what lets
+// compaction drop whole files instead of rewriting one giant log.
+const segmentMaxRecords = 10000
+
+// WAL is a write-ahead log for a single Store: every mutation is
+// appended here, fsync'd according to policy, and applied to the
+// in-memory map only afterwards. Periodic snapshots let old segments
+// be dropped instead of replayed forever.
+type WAL struct {
+	mu sync.Mutex
+
+	dir    string
+	policy FsyncPolicy
+
+	segment    *os.File
+	segmentSeq int
+	segmentN   int
+
+	requestNum uint64
+
+	done chan struct{}
+}
+
+// openWAL opens (creating if necessary) the WAL directory dir and
+// returns a WAL ready to accept appends. It does not replay anything;
+// callers that need recovery should call replay before appending.
+func openWAL(dir string, policy FsyncPolicy) (*WAL, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("store: unable to create wal dir %q: %w", dir, err)
+	}
+
+	w := &WAL{dir: dir, policy: policy, done: make(chan struct{})}
+
+	seqs, err := w.segmentSeqs()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seqs) == 0 {
+		if err := w.rollSegment(); err != nil {
+			return nil, err
+		}
+	} else if err := w.resumeSegment(seqs[len(seqs)-1]); err != nil {
+		return nil, err
+	}
+
+	if policy == FsyncEverySec {
+		go w.fsyncLoop()
+	}
+
+	return w, nil
+}
+
+func (w *WAL) segmentPath(seq int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("segment-%010d.log", seq))
+}
+
+func (w *WAL) snapshotPath(requestNum uint64) string {
+	return filepath.Join(w.dir, fmt.Sprintf("snapshot-%020d.json", requestNum))
+}
+
+// rollSegment closes the current segment file (if any) and opens the
+// next one.
+func (w *WAL) rollSegment() error {
+	if w.segment != nil {
+		w.segment.Close()
+	}
+
+	w.segmentSeq++
+	w.segmentN = 0
+
+	f, err := os.OpenFile(w.segmentPath(w.segmentSeq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("store: unable to create wal segment: %w", err)
+	}
+
+	w.segment = f
+	return nil
+}
+
+// resumeSegment reopens the existing segment seq for appending,
+// picking up segmentSeq and segmentN from what's already on disk so a
+// restart never reuses a sequence number that already has content
+// and appends land after whatever was written before the crash.
+func (w *WAL) resumeSegment(seq int) error {
+	n, err := recordCountIn(w.segmentPath(seq))
+	if err != nil {
+		return fmt.Errorf("store: unable to inspect wal segment: %w", err)
+	}
+
+	f, err := os.OpenFile(w.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("store: unable to reopen wal segment: %w", err)
+	}
+
+	w.segmentSeq = seq
+	w.segmentN = n
+	w.segment = f
+	return nil
+}
+
+// append writes record to the current segment, fsyncing according to
+// policy, and rolls to a new segment once the current one is full.
+func (w *WAL) append(op walOp, key string, value interface{}, expireAt int64) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.requestNum++
+	rec := walRecord{RequestNum: w.requestNum, Op: op, Key: key, Value: value, ExpireAt: expireAt}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return 0, fmt.Errorf("store: unable to encode wal record: %w", err)
+	}
+
+	if _, err := w.segment.Write(append(line, '\n')); err != nil {
+		return 0, fmt.Errorf("store: unable to append to wal: %w", err)
+	}
+
+	if w.policy == FsyncAlways {
+		w.segment.Sync()
+	}
+
+	w.segmentN++
+	if w.segmentN >= segmentMaxRecords {
+		if err := w.rollSegment(); err != nil {
+			return rec.RequestNum, err
+		}
+	}
+
+	return rec.RequestNum, nil
+}
+
+// fsyncLoop fsyncs the current segment once a second. Only started
+// under FsyncEverySec.
+func (w *WAL) fsyncLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.segment != nil {
+				w.segment.Sync()
+			}
+			w.mu.Unlock()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// segmentSeqs returns the sequence numbers of every segment file
+// currently on disk, sorted ascending.
+func (w *WAL) segmentSeqs() ([]int, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqs []int
+	for _, e := range entries {
+		var seq int
+		if _, err := fmt.Sscanf(e.Name(), "segment-%010d.log", &seq); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Ints(seqs)
+
+	return seqs, nil
+}
+
+// latestSnapshot returns the path and request number of the most
+// recent snapshot on disk, or ("", 0, false) if there is none.
+func (w *WAL) latestSnapshot() (string, uint64, bool) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return "", 0, false
+	}
+
+	var best string
+	var bestN uint64
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "snapshot-") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		numStr := strings.TrimSuffix(strings.TrimPrefix(name, "snapshot-"), ".json")
+		n, err := strconv.ParseUint(numStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if best == "" || n > bestN {
+			best, bestN = name, n
+		}
+	}
+
+	if best == "" {
+		return "", 0, false
+	}
+
+	return filepath.Join(w.dir, best), bestN, true
+}
+
+// replay rebuilds s by loading the latest snapshot (if any) and then
+// tailing every WAL segment whose records come after it, yielding
+// each request to s. Already-expired items are skipped rather than
+// reinserted.
+func (w *WAL) replay(s *Store) error {
+	afterRequestNum := uint64(0)
+
+	if path, n, ok := w.latestSnapshot(); ok {
+		if err := loadSnapshot(path, s); err != nil {
+			return fmt.Errorf("store: unable to load snapshot: %w", err)
+		}
+		afterRequestNum = n
+	}
+
+	return w.forEachRecord(afterRequestNum, func(rec walRecord) {
+		applyRecord(s, rec)
+	})
+}
+
+// forEachRecord scans every WAL segment in order and invokes fn for
+// each record whose RequestNum is greater than afterRequestNum. It
+// also advances w.requestNum past the highest RequestNum it sees, so
+// appends made after replay continue the same sequence.
+func (w *WAL) forEachRecord(afterRequestNum uint64, fn func(walRecord)) error {
+	seqs, err := w.segmentSeqs()
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range seqs {
+		f, err := os.Open(w.segmentPath(seq))
+		if err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var rec walRecord
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				continue
+			}
+
+			if rec.RequestNum > w.requestNum {
+				w.requestNum = rec.RequestNum
+			}
+			if rec.RequestNum > afterRequestNum {
+				fn(rec)
+			}
+		}
+		f.Close()
+	}
+
+	return nil
+}
+
+// RecoverFromRequestNumber replays every WAL record after
+// afterRequestNum, invoking fn for each one. It's meant for a
+// replication follower that already has state up to afterRequestNum
+// and wants to catch up without re-reading a snapshot.
+func (w *WAL) RecoverFromRequestNumber(afterRequestNum uint64, fn func(key string, data interface{}, expireAt int64, deleted bool)) error {
+	return w.forEachRecord(afterRequestNum, func(rec walRecord) {
+		fn(rec.Key, rec.Value, rec.ExpireAt, rec.Op == opDelete)
+	})
+}
+
+// applyRecord applies a single WAL record to s, skipping items that
+// have already expired by wall-clock time rather than reinserting
+// them only to have them expire on first read.
+func applyRecord(s *Store, rec walRecord) {
+	switch rec.Op {
+	case opSet, opExpire:
+		if rec.ExpireAt != NeverExpire && rec.ExpireAt < time.Now().UnixNano() {
+			delete(s.data, rec.Key)
+			return
+		}
+		s.data[rec.Key] = Item{expireAt: rec.ExpireAt, data: rec.Value}
+	case opDelete:
+		delete(s.data, rec.Key)
+	}
+}
+
+// snapshotItem is the on-disk form of an Item. Item's fields are
+// unexported so json can't see them directly; snapshotItem mirrors
+// walRecord's Value/ExpireAt so a snapshot round-trips the same data a
+// walRecord would.
+type snapshotItem struct {
+	Value    interface{} `json:"v,omitempty"`
+	ExpireAt int64       `json:"e"`
+}
+
+// snapshot writes the current contents of s to a new snapshot file,
+// then drops every WAL segment whose records are entirely captured
+// by it.
+func (w *WAL) snapshot(s *Store) error {
+	w.mu.Lock()
+	requestNum := w.requestNum
+	w.mu.Unlock()
+
+	s.mu.RLock()
+	dump := make(map[string]snapshotItem, len(s.data))
+	for k, v := range s.data {
+		dump[k] = snapshotItem{Value: v.data, ExpireAt: v.expireAt}
+	}
+	s.mu.RUnlock()
+
+	path := w.snapshotPath(requestNum)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("store: unable to create snapshot: %w", err)
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(dump); err != nil {
+		return fmt.Errorf("store: unable to write snapshot: %w", err)
+	}
+
+	return w.truncateBefore(requestNum)
+}
+
+// loadSnapshot loads a snapshot file written by snapshot into s,
+// skipping items that have already expired by wall-clock time.
+func loadSnapshot(path string, s *Store) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var dump map[string]snapshotItem
+	if err := json.NewDecoder(f).Decode(&dump); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UnixNano()
+	for k, v := range dump {
+		if v.ExpireAt != NeverExpire && v.ExpireAt < now {
+			continue
+		}
+		s.data[k] = Item{expireAt: v.ExpireAt, data: v.Value}
+	}
+
+	return nil
+}
+
+// truncateBefore removes every WAL segment whose highest RequestNum
+// is at or below requestNum, i.e. whose records are already captured
+// by a snapshot at requestNum. The current (still being written to)
+// segment is never removed.
+func (w *WAL) truncateBefore(requestNum uint64) error {
+	w.mu.Lock()
+	currentSeq := w.segmentSeq
+	w.mu.Unlock()
+
+	seqs, err := w.segmentSeqs()
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range seqs {
+		if seq == currentSeq {
+			continue
+		}
+
+		highest, err := highestRequestNumIn(w.segmentPath(seq))
+		if err != nil || highest > requestNum {
+			continue
+		}
+
+		os.Remove(w.segmentPath(seq))
+	}
+
+	return nil
+}
+
+// highestRequestNumIn scans a single segment file and returns the
+// highest RequestNum recorded in it.
+func highestRequestNumIn(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var highest uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec walRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err == nil && rec.RequestNum > highest {
+			highest = rec.RequestNum
+		}
+	}
+
+	return highest, nil
+}
+
+// recordCountIn scans a single segment file and returns how many
+// records it holds, so a resumed segment's segmentN starts accurate
+// instead of rolling early (or late) after a restart.
+func recordCountIn(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var n int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		n++
+	}
+
+	return n, nil
+}
+
+// compactionLoop periodically snapshots s and truncates the WAL.
+// Compaction runs until the WAL is closed; NewDurable never closes
+// it, so for a durable Store it runs for the lifetime of the process,
+// like the ttlSweeper goroutine in sweeper.go.
+func (w *WAL) compactionLoop(s *Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.snapshot(s)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Close stops the WAL's background goroutines and closes the
+// current segment file.
+func (w *WAL) Close() error {
+	close(w.done)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.segment != nil {
+		return w.segment.Close()
+	}
+
+	return nil
+}
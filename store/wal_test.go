@@ -0,0 +1,69 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWALResumeAfterRestart simulates a process crash and restart:
+// it writes enough records to roll past the first segment, reopens
+// the WAL (as a fresh process would), writes one more record, and
+// reopens it again. The final replay must see the second-run write,
+// not silently reorder it behind the first run's higher-numbered
+// segment.
+func TestWALResumeAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	wal, err := openWAL(dir, FsyncNever)
+	if err != nil {
+		t.Fatalf("openWAL: %v", err)
+	}
+
+	for i := 0; i < segmentMaxRecords+1; i++ {
+		if _, err := wal.append(opSet, "filler", i, NeverExpire); err != nil {
+			t.Fatalf("append filler %d: %v", i, err)
+		}
+	}
+	if _, err := wal.append(opSet, "X", "old", NeverExpire); err != nil {
+		t.Fatalf("append X=old: %v", err)
+	}
+	if wal.segmentSeq < 2 {
+		t.Fatalf("expected to have rolled into segment 2, got segmentSeq=%d", wal.segmentSeq)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	wal, err = openWAL(dir, FsyncNever)
+	if err != nil {
+		t.Fatalf("reopen wal: %v", err)
+	}
+	if wal.segmentSeq < 2 {
+		t.Fatalf("reopen should resume at the highest existing segment, got segmentSeq=%d", wal.segmentSeq)
+	}
+	if _, err := wal.append(opSet, "X", "new", NeverExpire); err != nil {
+		t.Fatalf("append X=new: %v", err)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	wal, err = openWAL(dir, FsyncNever)
+	if err != nil {
+		t.Fatalf("reopen wal after second run: %v", err)
+	}
+	defer wal.Close()
+
+	s := New(time.Hour)
+	if err := wal.replay(s); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	got, ok := s.Get("X")
+	if !ok {
+		t.Fatalf("X missing after replay")
+	}
+	if got != "new" {
+		t.Fatalf("X = %v, want %q (replay reordered writes across segments)", got, "new")
+	}
+}
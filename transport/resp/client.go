@@ -0,0 +1,47 @@
+package resp
+
+import (
+	"bufio"
+	"log"
+	"net"
+)
+
+// Client wires a single connection to a Driver, the RESP equivalent
+// of transport.Client: it owns the net.Conn and turns bytes off the
+// wire into commands for the Driver to execute instead of splitting
+// on ';' and parsing RQL. One Client (and one Driver) is created per
+// connection.
+type Client struct {
+	conn   net.Conn
+	log    *log.Logger
+	driver *Driver
+}
+
+// NewClient returns a new Client for conn.
+func NewClient(conn net.Conn, log *log.Logger, driver *Driver) *Client {
+	return &Client{conn: conn, log: log, driver: driver}
+}
+
+// InitRead reads RESP commands off the connection and executes them
+// one at a time until the connection is closed, then cleans up: the
+// driver unsubscribes from everything it was subscribed to so a
+// disconnected client doesn't leak a pub/sub subscription.
+func (c *Client) InitRead() {
+	defer c.conn.Close()
+	defer c.driver.Close()
+
+	r := bufio.NewReader(c.conn)
+	for {
+		args, err := ReadCommand(r)
+		if err != nil {
+			break
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		c.driver.Operate(args, c.conn)
+	}
+
+	c.log.Println("Disconnected: ", c.conn.RemoteAddr().String())
+}
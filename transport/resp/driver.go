@@ -0,0 +1,477 @@
+package resp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkarsh-pro/RapidoDB/pubsub"
+	"github.com/utkarsh-pro/RapidoDB/security"
+)
+
+// DB is the interface the RESP driver expects of the database. It is
+// identical in shape to rql.SecureDB; the two packages each define
+// their own copy so that neither depends on the other - the same way
+// security.UnsecureDB and rql.SecureDB are kept independent.
+type DB interface {
+	Set(key string, data interface{}, expireIn time.Duration)
+	Get(key string) (interface{}, bool)
+	Delete(keys ...string) int
+	Update(key string, data interface{}) bool
+	Exists(keys ...string) int
+	Incr(key string, by int64) (int64, error)
+	TTL(key string) (time.Duration, bool)
+	Expire(key string, expireIn time.Duration) bool
+	Authenticate(username, password string) (security.Principal, bool)
+	DefaultPrincipal() security.Principal
+	CreateUser(username, password string) bool
+	Grant(username string, classes security.CommandClass, pattern string, allow bool) bool
+	Subscribe(pattern string) <-chan pubsub.Event
+	Unsubscribe(ch <-chan pubsub.Event)
+	Publish(channel, message string) int
+}
+
+// Driver is the RESP equivalent of rql.Driver: it translates RESP
+// commands straight into DB calls instead of going through the RQL
+// lexer/parser, so redis-cli and Redis client libraries can talk to
+// the same database every rql.Driver serves.
+type Driver struct {
+	db DB
+
+	// principal is the currently authenticated user for this
+	// connection; it starts out as db.DefaultPrincipal() and is
+	// replaced on a successful AUTH, same as rql.Driver.
+	principal security.Principal
+
+	subsMu sync.Mutex
+	subs   map[string]<-chan pubsub.Event
+}
+
+// NewDriver returns a new Driver for db.
+func NewDriver(db DB) *Driver {
+	return &Driver{db: db, principal: db.DefaultPrincipal(), subs: make(map[string]<-chan pubsub.Event)}
+}
+
+// Close unsubscribes the driver from every pattern it is currently
+// subscribed to; it should be called once the connection this Driver
+// belongs to goes away.
+func (d *Driver) Close() {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+
+	for pattern, ch := range d.subs {
+		d.db.Unsubscribe(ch)
+		delete(d.subs, pattern)
+	}
+}
+
+// Operate executes a single RESP command, writing its reply to w in
+// RESP wire format.
+func (d *Driver) Operate(args []string, w io.Writer) {
+	if len(args) == 0 {
+		return
+	}
+
+	cmd := strings.ToUpper(args[0])
+	args = args[1:]
+
+	if err := d.authorize(cmd, args); err != nil {
+		WriteError(w, err.Error())
+		return
+	}
+
+	switch cmd {
+	case "PING":
+		d.ping(args, w)
+	case "AUTH":
+		d.auth(args, w)
+	case "SET":
+		d.set(args, w)
+	case "GET":
+		d.get(args, w)
+	case "DEL":
+		d.del(args, w)
+	case "UPDATE":
+		d.update(args, w)
+	case "EXISTS":
+		d.exists(args, w)
+	case "INCR":
+		d.incr(args, w)
+	case "DECR":
+		d.decr(args, w)
+	case "TTL":
+		d.ttl(args, w)
+	case "EXPIRE":
+		d.expire(args, w)
+	case "SUBSCRIBE":
+		d.subscribe(args, w)
+	case "UNSUBSCRIBE":
+		d.unsubscribe(args, w)
+	case "PUBLISH":
+		d.publish(args, w)
+	case "CREATEUSER":
+		d.createUser(args, w)
+	case "GRANT":
+		d.grant(args, w)
+	case "REVOKE":
+		d.revoke(args, w)
+	case "WHOAMI":
+		d.whoami(w)
+	default:
+		WriteError(w, fmt.Sprintf("unknown command %q", cmd))
+	}
+}
+
+// authorize checks cmd/args against the connection's current
+// principal, mirroring rql.Driver.authorize. AUTH, PING and WHOAMI
+// are always allowed since a connection needs some way to
+// authenticate and introspect itself regardless of its current
+// permissions.
+func (d *Driver) authorize(cmd string, args []string) error {
+	class, keys := commandClassOf(cmd, args)
+	if class == 0 {
+		return nil
+	}
+	if len(keys) == 0 {
+		keys = []string{"*"}
+	}
+
+	for _, key := range keys {
+		if !d.principal.Can(class, key) {
+			return fmt.Errorf("permission denied for user %q on %q", d.principal.Username, key)
+		}
+	}
+
+	return nil
+}
+
+// commandClassOf returns the CommandClass cmd belongs to, and the
+// keys it touches (for per-key pattern matching). A zero CommandClass
+// means the command needs no authorization.
+func commandClassOf(cmd string, args []string) (security.CommandClass, []string) {
+	switch cmd {
+	case "GET", "EXISTS", "DEL":
+		return classFor(cmd), args
+	case "TTL", "SET", "UPDATE", "INCR", "DECR", "EXPIRE":
+		if len(args) == 0 {
+			return classFor(cmd), nil
+		}
+		return classFor(cmd), []string{args[0]}
+	case "SUBSCRIBE", "UNSUBSCRIBE", "PUBLISH":
+		return security.PubSubCommand, nil
+	case "CREATEUSER", "GRANT", "REVOKE":
+		return security.AdminCommand, nil
+	default:
+		return 0, nil
+	}
+}
+
+// classFor returns the CommandClass a data command belongs to.
+func classFor(cmd string) security.CommandClass {
+	switch cmd {
+	case "GET", "EXISTS", "TTL":
+		return security.ReadCommand
+	default:
+		return security.WriteCommand
+	}
+}
+
+// ping replies with PONG, or echoes args[0] if given, matching real
+// Redis' PING behaviour.
+func (d *Driver) ping(args []string, w io.Writer) {
+	if len(args) > 0 {
+		WriteBulkString(w, args[0], true)
+		return
+	}
+
+	WriteSimpleString(w, "PONG")
+}
+
+func (d *Driver) auth(args []string, w io.Writer) {
+	if len(args) != 2 {
+		WriteError(w, "AUTH requires a username and a password")
+		return
+	}
+
+	principal, ok := d.db.Authenticate(args[0], args[1])
+	if !ok {
+		WriteError(w, "invalid credentials")
+		return
+	}
+
+	d.principal = principal
+	WriteSimpleString(w, "OK")
+}
+
+// set handles `SET key value [EX seconds]`.
+func (d *Driver) set(args []string, w io.Writer) {
+	if len(args) != 2 && len(args) != 4 {
+		WriteError(w, "SET requires a key and a value, optionally followed by EX seconds")
+		return
+	}
+
+	var expireIn time.Duration
+	if len(args) == 4 {
+		if strings.ToUpper(args[2]) != "EX" {
+			WriteError(w, fmt.Sprintf("unsupported SET option %q", args[2]))
+			return
+		}
+
+		seconds, err := strconv.ParseInt(args[3], 10, 64)
+		if err != nil {
+			WriteError(w, "invalid EX value")
+			return
+		}
+
+		expireIn = time.Duration(seconds) * time.Second
+	}
+
+	d.db.Set(args[0], args[1], expireIn)
+	WriteSimpleString(w, "OK")
+}
+
+func (d *Driver) get(args []string, w io.Writer) {
+	if len(args) != 1 {
+		WriteError(w, "GET requires exactly one key")
+		return
+	}
+
+	val, ok := d.db.Get(args[0])
+	if !ok {
+		WriteBulkString(w, "", false)
+		return
+	}
+
+	WriteBulkString(w, fmt.Sprintf("%v", val), true)
+}
+
+func (d *Driver) del(args []string, w io.Writer) {
+	WriteInteger(w, int64(d.db.Delete(args...)))
+}
+
+// update handles `UPDATE key value`, replacing the value stored
+// under key without touching its existing expiry.
+func (d *Driver) update(args []string, w io.Writer) {
+	if len(args) != 2 {
+		WriteError(w, "UPDATE requires a key and a value")
+		return
+	}
+
+	if d.db.Update(args[0], args[1]) {
+		WriteSimpleString(w, "OK")
+		return
+	}
+
+	WriteError(w, "key does not exist")
+}
+
+func (d *Driver) exists(args []string, w io.Writer) {
+	WriteInteger(w, int64(d.db.Exists(args...)))
+}
+
+func (d *Driver) incr(args []string, w io.Writer) {
+	d.incrBy(args, 1, w)
+}
+
+// decr is implemented in terms of incrBy with a negated sign since
+// the underlying semantics are identical, the same relationship
+// rql.Driver.decr has to Incr.
+func (d *Driver) decr(args []string, w io.Writer) {
+	d.incrBy(args, -1, w)
+}
+
+func (d *Driver) incrBy(args []string, sign int64, w io.Writer) {
+	if len(args) != 1 && len(args) != 2 {
+		WriteError(w, "requires a key and an optional amount")
+		return
+	}
+
+	by := sign
+	if len(args) == 2 {
+		amount, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			WriteError(w, "invalid amount")
+			return
+		}
+		by = sign * amount
+	}
+
+	val, err := d.db.Incr(args[0], by)
+	if err != nil {
+		WriteError(w, err.Error())
+		return
+	}
+
+	WriteInteger(w, val)
+}
+
+// ttl replies with the remaining time to live for a key, in seconds,
+// or -1 if the key has no expiry and -2 if it doesn't exist.
+func (d *Driver) ttl(args []string, w io.Writer) {
+	if len(args) != 1 {
+		WriteError(w, "TTL requires exactly one key")
+		return
+	}
+
+	remaining, ok := d.db.TTL(args[0])
+	if !ok {
+		WriteInteger(w, -2)
+		return
+	}
+	if remaining == 0 {
+		WriteInteger(w, -1)
+		return
+	}
+
+	WriteInteger(w, int64(remaining.Seconds()))
+}
+
+func (d *Driver) expire(args []string, w io.Writer) {
+	if len(args) != 2 {
+		WriteError(w, "EXPIRE requires a key and a number of seconds")
+		return
+	}
+
+	seconds, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		WriteError(w, "invalid expiry")
+		return
+	}
+
+	if d.db.Expire(args[0], time.Duration(seconds)*time.Second) {
+		WriteSimpleString(w, "OK")
+		return
+	}
+
+	WriteError(w, "key does not exist")
+}
+
+// subscribe subscribes the connection to every pattern in args that
+// it isn't already subscribed to, and starts forwarding matching
+// events to w as they arrive.
+func (d *Driver) subscribe(args []string, w io.Writer) {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+
+	for _, pattern := range args {
+		if _, ok := d.subs[pattern]; ok {
+			continue
+		}
+
+		ch := d.db.Subscribe(pattern)
+		d.subs[pattern] = ch
+
+		go forwardEvents(ch, w)
+	}
+
+	WriteSimpleString(w, "Subscribed to "+strings.Join(args, ", "))
+}
+
+// unsubscribe stops forwarding events for every pattern in args, or
+// every pattern the connection is currently subscribed to if args is
+// empty.
+func (d *Driver) unsubscribe(args []string, w io.Writer) {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+
+	patterns := args
+	if len(patterns) == 0 {
+		for pattern := range d.subs {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if ch, ok := d.subs[pattern]; ok {
+			d.db.Unsubscribe(ch)
+			delete(d.subs, pattern)
+		}
+	}
+
+	WriteSimpleString(w, "Unsubscribed from "+strings.Join(patterns, ", "))
+}
+
+func (d *Driver) publish(args []string, w io.Writer) {
+	if len(args) != 2 {
+		WriteError(w, "PUBLISH requires a channel and a message")
+		return
+	}
+
+	WriteInteger(w, int64(d.db.Publish(args[0], args[1])))
+}
+
+func (d *Driver) createUser(args []string, w io.Writer) {
+	if len(args) != 2 {
+		WriteError(w, "CREATEUSER requires a username and a password")
+		return
+	}
+
+	if d.db.CreateUser(args[0], args[1]) {
+		WriteSimpleString(w, "OK")
+		return
+	}
+
+	WriteError(w, "user already exists")
+}
+
+func (d *Driver) grant(args []string, w io.Writer) {
+	d.grantOrRevoke(args, true, w)
+}
+
+func (d *Driver) revoke(args []string, w io.Writer) {
+	d.grantOrRevoke(args, false, w)
+}
+
+// grantOrRevoke implements GRANT/REVOKE, which only differ in the
+// allow flag they pass through to DB.Grant - same relationship
+// rql.Driver.grant/revoke have to security.Security.Grant.
+func (d *Driver) grantOrRevoke(args []string, allow bool, w io.Writer) {
+	if len(args) != 3 {
+		WriteError(w, "requires a username, command classes and a key pattern")
+		return
+	}
+
+	classes, err := security.ParseCommandClasses(args[1])
+	if err != nil {
+		WriteError(w, err.Error())
+		return
+	}
+
+	if d.db.Grant(args[0], classes, args[2], allow) {
+		WriteSimpleString(w, "OK")
+		return
+	}
+
+	WriteError(w, "user does not exist")
+}
+
+func (d *Driver) whoami(w io.Writer) {
+	WriteBulkString(w, d.principal.Username, true)
+}
+
+// forwardEvents writes every Event received on ch to w as a RESP
+// pub/sub push message until ch is closed (by Unsubscribe or
+// Driver.Close), the 3-element "message"/channel/payload array real
+// Redis clients expect. It mirrors rql.forwardEvents.
+//
+// Every other reply in this package is a single Write* call, which is
+// what lets concurrent writers share w safely (see transport.go's
+// note on that invariant). A pub/sub push is 4 such calls, so it's
+// built into a buffer first and flushed with one Write - otherwise it
+// could interleave with a reply an unrelated goroutine is writing to
+// the same connection at the same time.
+func forwardEvents(ch <-chan pubsub.Event, w io.Writer) {
+	for ev := range ch {
+		var buf bytes.Buffer
+		WriteArray(&buf, 3)
+		WriteBulkString(&buf, "message", true)
+		WriteBulkString(&buf, ev.Channel, true)
+		WriteBulkString(&buf, ev.Message, true)
+
+		w.Write(buf.Bytes())
+	}
+}
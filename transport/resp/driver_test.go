@@ -0,0 +1,40 @@
+package resp
+
+import (
+	"testing"
+
+	"github.com/utkarsh-pro/RapidoDB/pubsub"
+)
+
+// countingWriter records how many Write calls it received, so tests
+// can assert a frame was flushed as a single write instead of one
+// write per field - multiple writes on a connection shared with
+// another goroutine can interleave with that goroutine's own frame.
+type countingWriter struct {
+	writes [][]byte
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	cp := append([]byte(nil), p...)
+	w.writes = append(w.writes, cp)
+	return len(p), nil
+}
+
+func TestForwardEventsWritesOneFrameAtATime(t *testing.T) {
+	ch := make(chan pubsub.Event, 1)
+	w := &countingWriter{}
+
+	ch <- pubsub.Event{Channel: "cache:foo", Message: "set"}
+	close(ch)
+
+	forwardEvents(ch, w)
+
+	if len(w.writes) != 1 {
+		t.Fatalf("forwardEvents issued %d Write calls for one event, want 1", len(w.writes))
+	}
+
+	want := "*3\r\n$7\r\nmessage\r\n$9\r\ncache:foo\r\n$3\r\nset\r\n"
+	if got := string(w.writes[0]); got != want {
+		t.Errorf("frame = %q, want %q", got, want)
+	}
+}
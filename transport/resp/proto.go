@@ -0,0 +1,122 @@
+/*
+	resp package is an alternate transport+translation codec sitting
+	alongside rql: it speaks the Redis Serialization Protocol so
+	redis-cli and Redis client libraries can talk to RapidoDB without
+	going through the RQL lexer/parser at all. Like rql, it talks to
+	the database purely through an interface it defines itself (DB),
+	so it stays independent of the storage/security layers'
+	implementation, same as every other layer in RapidoDB.
+*/
+package resp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadCommand reads a single command from r: either a multi-bulk
+// array of bulk strings (the format real clients send) or a plain
+// inline command - space-separated, newline-terminated - which RESP
+// has always accepted as a convenience for manual testing over e.g.
+// telnet. It returns a nil slice, with no error, for a blank line.
+func ReadCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	if line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, fmt.Errorf("resp: invalid multibulk length %q", line[1:])
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		arg, err := readBulkString(r)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+// readLine reads a single CRLF- or LF-terminated line from r, with
+// the terminator stripped.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readBulkString reads a single "$<len>\r\n<data>\r\n" bulk string.
+func readBulkString(r *bufio.Reader) (string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return "", err
+	}
+	if len(line) == 0 || line[0] != '$' {
+		return "", fmt.Errorf("resp: expected bulk string, got %q", line)
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return "", fmt.Errorf("resp: invalid bulk length %q", line[1:])
+	}
+	if n < 0 {
+		return "", nil
+	}
+
+	buf := make([]byte, n+2) // +2 for the trailing CRLF
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+
+	return string(buf[:n]), nil
+}
+
+// WriteSimpleString writes a RESP simple string: "+s\r\n".
+func WriteSimpleString(w io.Writer, s string) {
+	fmt.Fprintf(w, "+%s\r\n", s)
+}
+
+// WriteError writes a RESP error: "-ERR msg\r\n".
+func WriteError(w io.Writer, msg string) {
+	fmt.Fprintf(w, "-ERR %s\r\n", msg)
+}
+
+// WriteInteger writes a RESP integer: ":n\r\n".
+func WriteInteger(w io.Writer, n int64) {
+	fmt.Fprintf(w, ":%d\r\n", n)
+}
+
+// WriteBulkString writes a RESP bulk string, or the null bulk string
+// ("$-1\r\n") if ok is false.
+func WriteBulkString(w io.Writer, s string, ok bool) {
+	if !ok {
+		fmt.Fprint(w, "$-1\r\n")
+		return
+	}
+
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+// WriteArray writes the header for an array of n elements; the
+// caller writes each element with the Write* functions above.
+func WriteArray(w io.Writer, n int) {
+	fmt.Fprintf(w, "*%d\r\n", n)
+}
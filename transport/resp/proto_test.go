@@ -0,0 +1,73 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadCommandMultiBulk(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n"))
+
+	args, err := ReadCommand(r)
+	if err != nil {
+		t.Fatalf("ReadCommand: %v", err)
+	}
+
+	want := []string{"SET", "k", "v"}
+	if len(args) != len(want) {
+		t.Fatalf("args = %v, want %v", args, want)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Errorf("args[%d] = %q, want %q", i, args[i], want[i])
+		}
+	}
+}
+
+func TestReadCommandInline(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("PING\r\n"))
+
+	args, err := ReadCommand(r)
+	if err != nil {
+		t.Fatalf("ReadCommand: %v", err)
+	}
+	if len(args) != 1 || args[0] != "PING" {
+		t.Fatalf("args = %v, want [PING]", args)
+	}
+}
+
+func TestReadCommandBlankLine(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("\r\n"))
+
+	args, err := ReadCommand(r)
+	if err != nil {
+		t.Fatalf("ReadCommand: %v", err)
+	}
+	if args != nil {
+		t.Fatalf("args = %v, want nil", args)
+	}
+}
+
+func TestWriteBulkString(t *testing.T) {
+	var buf bytes.Buffer
+	WriteBulkString(&buf, "hello", true)
+	if got, want := buf.String(), "$5\r\nhello\r\n"; got != want {
+		t.Errorf("WriteBulkString = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	WriteBulkString(&buf, "", false)
+	if got, want := buf.String(), "$-1\r\n"; got != want {
+		t.Errorf("WriteBulkString (nil) = %q, want %q", got, want)
+	}
+}
+
+func TestWriteInteger(t *testing.T) {
+	var buf bytes.Buffer
+	WriteInteger(&buf, 42)
+	if got, want := buf.String(), ":42\r\n"; got != want {
+		t.Errorf("WriteInteger = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+	transport package is the outermost layer of RapidoDB: it owns the
+	raw net.Conn for a client and is responsible for turning bytes on
+	the wire into RQL statements (and, once a client has subscribed,
+	for multiplexing asynchronous pub/sub frames back out alongside
+	ordinary command responses).
+*/
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"net"
+)
+
+// Driver is the interface Client expects from its RQL driver. It's
+// satisfied by *rql.Driver; Client doesn't import rql directly so
+// that transport stays independent of the translation layer's
+// implementation, same as every other layer in RapidoDB.
+type Driver interface {
+	Operate(src string, w io.Writer)
+	Close()
+}
+
+// Client wires a single connection to a Driver. One Client (and one
+// Driver) is created per connection.
+//
+// Subscriptions don't need any special handling here: once
+// Driver.Operate executes a SUBSCRIBE statement it starts pushing
+// MESSAGE frames to conn itself from its own goroutine, and net.Conn
+// is safe for concurrent writes, so those frames simply interleave
+// with whatever InitRead's loop is writing for ordinary commands.
+type Client struct {
+	conn   net.Conn
+	log    *log.Logger
+	driver Driver
+}
+
+// New returns a new Client for conn.
+func New(conn net.Conn, log *log.Logger, driver Driver) *Client {
+	return &Client{conn: conn, log: log, driver: driver}
+}
+
+// InitRead reads RQL statements off the connection and executes them
+// one at a time until the connection is closed, then cleans up: the
+// driver unsubscribes from everything it was subscribed to so a
+// disconnected client doesn't leak a pub/sub subscription.
+func (c *Client) InitRead() {
+	defer c.conn.Close()
+	defer c.driver.Close()
+
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Split(scanStatements)
+
+	for scanner.Scan() {
+		c.driver.Operate(scanner.Text(), c.conn)
+	}
+
+	c.log.Println("Disconnected: ", c.conn.RemoteAddr().String())
+}
+
+// scanStatements is a bufio.SplitFunc that splits on ';', the RQL
+// statement terminator, instead of bufio.ScanLines' newlines.
+func scanStatements(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexByte(data, ';'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}